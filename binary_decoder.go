@@ -0,0 +1,106 @@
+package php_session_decoder
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/solidwall/php_session_decoder/php_serialize"
+)
+
+func DecodeBinary(phpSession string) (*PhpSession, error) {
+	decoder := NewPhpBinaryDecoder(phpSession)
+	return decoder.Decode()
+}
+
+// PhpBinaryDecoder decodes session data written with session.serialize_handler=php_binary.
+type PhpBinaryDecoder struct {
+	source     *sessionReader
+	decoder    *php_serialize.UnSerializer
+	decodeFunc php_serialize.SerializedDecodeFunc
+}
+
+func NewPhpBinaryDecoder(phpSession string) *PhpBinaryDecoder {
+	return NewPhpBinaryDecoderFromReader(strings.NewReader(phpSession))
+}
+
+// NewPhpBinaryDecoderFromReader creates decoder which reads session data directly from r.
+func NewPhpBinaryDecoderFromReader(r io.Reader) *PhpBinaryDecoder {
+	decoder := &PhpBinaryDecoder{
+		source:  newSessionReader(r),
+		decoder: php_serialize.NewUnSerializer(""),
+	}
+	decoder.decoder.SetRuneReader(decoder.source)
+	return decoder
+}
+
+func (pd *PhpBinaryDecoder) SetSerializedDecodeFunc(f php_serialize.SerializedDecodeFunc) {
+	pd.decodeFunc = f
+	pd.decoder.SetSerializedDecodeFunc(f)
+}
+
+func (pd *PhpBinaryDecoder) Decode() (*PhpSession, error) {
+	return pd.decode(nil)
+}
+
+// DecodeKeys decodes only given variables, values of others are skipped
+// and decoding stops as soon as all of them are found.
+func (pd *PhpBinaryDecoder) DecodeKeys(names ...string) (*PhpSession, error) {
+	return pd.decode(newNameFilter(names))
+}
+
+func (pd *PhpBinaryDecoder) decode(filter nameFilter) (*PhpSession, error) {
+	var (
+		name    string
+		defined bool
+		err     error
+		value   php_serialize.PhpValue
+	)
+	res := NewPhpSession()
+	res.decodeFunc = pd.decodeFunc
+
+	for {
+		if name, defined, err = pd.readName(); err != nil {
+			break
+		}
+		if !defined {
+			if filter.match(name) {
+				res.setDecoded(name, PhpUndefined{}, "")
+			}
+		} else if pd.source.atEOF() {
+			err = fmt.Errorf("php_session: missing value for %q", name)
+			break
+		} else if !filter.match(name) {
+			if err = pd.decoder.Skip(); err != nil {
+				break
+			}
+		} else {
+			pd.source.startRecord()
+			if value, err = pd.decoder.Decode(); err != nil {
+				break
+			}
+			res.setDecoded(name, value, pd.source.stopRecord())
+		}
+		if filter.done(res) {
+			break
+		}
+	}
+
+	if err == io.EOF {
+		err = nil
+	}
+	return res, err
+}
+
+func (pd *PhpBinaryDecoder) readName() (string, bool, error) {
+	length, err := pd.source.ReadByte()
+	if err != nil {
+		return "", false, err
+	}
+
+	buf := make([]byte, int(length&^BINARY_UNDEFINED_FLAG))
+	if _, err = io.ReadFull(pd.source, buf); err != nil {
+		return "", false, fmt.Errorf("php_session: unable to read name of %d bytes: %v", len(buf), err)
+	}
+	return string(buf), length&BINARY_UNDEFINED_FLAG == 0, nil
+}
@@ -0,0 +1,75 @@
+package php_session_decoder
+
+import (
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/solidwall/php_session_decoder/php_serialize"
+)
+
+func TestBinaryDecodeValues(t *testing.T) {
+	decoder := NewPhpBinaryDecoder("\x08login_okb:1;\x07inteiroi:34;\x03arra:1:{s:4:\"test\";b:1;}")
+	if result, err := decoder.Decode(); err != nil {
+		t.Errorf("Can not decode binary session %#v \n", err)
+	} else {
+		if v, ok := result.Get("login_ok"); !ok || v != true {
+			t.Errorf("Boolean value was decoded incorrectly: %#v\n", v)
+		}
+		if v, ok := result.Get("inteiro"); !ok || v != 34 {
+			t.Errorf("Int value was decoded incorrectly: %#v\n", v)
+		}
+		if v, ok := result.Get("arr"); !ok {
+			t.Errorf("Array value was not decoded \n")
+		} else if arrValue, ok := v.(php_serialize.PhpArray); !ok || arrValue["test"] != true {
+			t.Errorf("Array value was decoded incorrectly: %#v\n", v)
+		}
+	}
+}
+
+func TestBinaryDecodeNameWithSeparator(t *testing.T) {
+	decoder := NewPhpBinaryDecoder("\x03a|bs:1:\"x\";")
+	if result, err := decoder.Decode(); err != nil {
+		t.Errorf("Can not decode binary session %#v \n", err)
+	} else if v, ok := result.Get("a|b"); !ok || v != "x" {
+		t.Errorf("Value was decoded incorrectly: %#v\n", result)
+	}
+}
+
+func TestBinaryDecodeUndefinedValue(t *testing.T) {
+	decoder := NewPhpBinaryDecoder("\x85undef\x07inteiroi:34;")
+	if result, err := decoder.Decode(); err != nil {
+		t.Errorf("Can not decode binary session %#v \n", err)
+	} else if !result.IsUndefined("undef") {
+		t.Errorf("Undefined value was decoded incorrectly: %#v\n", result)
+	} else if v, ok := result.Get("inteiro"); !ok || v != 34 {
+		t.Errorf("Int value was decoded incorrectly: %#v\n", result)
+	}
+}
+
+func TestBinaryDecodeTruncated(t *testing.T) {
+	for _, data := range []string{"\x08login", "\x08login_ok"} {
+		if _, err := DecodeBinary(data); err == nil {
+			t.Errorf("Truncated session %q should not be decoded\n", data)
+		}
+	}
+}
+
+func TestBinaryDecodeFromReader(t *testing.T) {
+	source := strings.NewReader("\x85undef\x07inteiroi:34;\x04names:8:\"тест\";")
+	decoder := NewPhpBinaryDecoderFromReader(iotest.OneByteReader(source))
+	if result, err := decoder.Decode(); err != nil {
+		t.Errorf("Can not decode binary session from reader %#v \n", err)
+	} else if v, ok := result.Get("name"); !ok || v != "тест" || !result.IsUndefined("undef") {
+		t.Errorf("Binary session was decoded incorrectly: %#v\n", result)
+	}
+}
+
+func TestBinaryDecodeKeys(t *testing.T) {
+	decoder := NewPhpBinaryDecoder("\x04skipa:1:{i:0;s:1:\"x\";}\x07user_idi:42;\x06broken")
+	if result, err := decoder.DecodeKeys("user_id"); err != nil {
+		t.Errorf("Can not decode selected keys %#v \n", err)
+	} else if v, ok := result.Get("user_id"); !ok || v != 42 || result.Len() != 1 {
+		t.Errorf("Selected keys were decoded incorrectly: %#v\n", result)
+	}
+}
@@ -0,0 +1,86 @@
+package php_session_decoder
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/solidwall/php_session_decoder/php_serialize"
+)
+
+func EncodeBinary(data *PhpSession) (string, error) {
+	encoder := NewPhpBinaryEncoder(data)
+	return encoder.Encode()
+}
+
+// PhpBinaryEncoder encodes session data for session.serialize_handler=php_binary.
+type PhpBinaryEncoder struct {
+	data             *PhpSession
+	encoder          *php_serialize.Serializer
+	dropInvalidNames bool
+}
+
+func NewPhpBinaryEncoder(data *PhpSession) *PhpBinaryEncoder {
+	return &PhpBinaryEncoder{
+		data:    data,
+		encoder: php_serialize.NewSerializer(),
+	}
+}
+
+func (pe *PhpBinaryEncoder) SetSerializedEncodeFunc(f php_serialize.SerializedEncodeFunc) {
+	pe.encoder.SetSerializedEncodeFunc(f)
+}
+
+// SetDropInvalidNames makes Encode skip variables which names can't be written
+// by the handler, like session_encode does, instead of returning ErrInvalidName.
+func (pe *PhpBinaryEncoder) SetDropInvalidNames(drop bool) {
+	pe.dropInvalidNames = drop
+}
+
+func (pe *PhpBinaryEncoder) Encode() (string, error) {
+	buf := bytes.NewBuffer([]byte{})
+	err := pe.encode(buf)
+	return buf.String(), err
+}
+
+// EncodeTo writes encoded session to w without building it in memory first.
+func (pe *PhpBinaryEncoder) EncodeTo(w io.Writer) error {
+	return encodeTo(w, pe.encode)
+}
+
+// AppendEncode appends encoded session to dst and returns the extended buffer.
+func (pe *PhpBinaryEncoder) AppendEncode(dst []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(dst)
+	err := pe.encode(buf)
+	return buf.Bytes(), err
+}
+
+func (pe *PhpBinaryEncoder) encode(buf php_serialize.Writer) error {
+	if pe.data == nil {
+		return nil
+	}
+	var err error
+
+	for _, k := range pe.data.names {
+		if err = validateBinaryName(k); err != nil {
+			if pe.dropInvalidNames {
+				err = nil
+				continue
+			}
+			break
+		}
+		if pe.data.IsUndefined(k) {
+			buf.WriteByte(byte(len(k)) | BINARY_UNDEFINED_FLAG)
+			buf.WriteString(k)
+			continue
+		}
+		buf.WriteByte(byte(len(k)))
+		buf.WriteString(k)
+		if err = pe.data.encodeValue(buf, k, pe.encoder); err != nil {
+			err = fmt.Errorf("php_session: error during encode value for %q: %v", k, err)
+			break
+		}
+	}
+
+	return err
+}
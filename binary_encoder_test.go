@@ -0,0 +1,64 @@
+package php_session_decoder
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestBinaryEncodeValue(t *testing.T) {
+	data := NewPhpSession().Set("login_ok", true)
+
+	encoder := NewPhpBinaryEncoder(data)
+	if result, err := encoder.Encode(); err != nil {
+		t.Errorf("Can not encode binary session %#v \n", err)
+	} else if result != "\x08login_okb:1;" {
+		t.Errorf("Boolean value was encoded incorrectly %q \n", result)
+	}
+}
+
+func TestBinaryEncodeRoundTrip(t *testing.T) {
+	data := NewPhpSession().Set("inteiro", 34).Set("a|b", "text")
+
+	if encoded, err := EncodeBinary(data); err != nil {
+		t.Errorf("Can not encode binary session %#v \n", err)
+	} else if result, err := DecodeBinary(encoded); err != nil {
+		t.Errorf("Can not decode binary session %#v \n", err)
+	} else if result.Len() != 2 {
+		t.Errorf("Session was encoded incorrectly %q \n", encoded)
+	} else if v, _ := result.Get("inteiro"); v != 34 {
+		t.Errorf("Session was encoded incorrectly %q \n", encoded)
+	} else if v, _ := result.Get("a|b"); v != "text" {
+		t.Errorf("Session was encoded incorrectly %q \n", encoded)
+	}
+}
+
+func TestBinaryEncodeLongName(t *testing.T) {
+	data := NewPhpSession().Set(strings.Repeat("a", BINARY_MAX_NAME_LENGTH+1), 1)
+
+	if _, err := EncodeBinary(data); !errors.Is(err, ErrInvalidName) {
+		t.Errorf("Name longer than %d bytes should not be encoded\n", BINARY_MAX_NAME_LENGTH)
+	}
+}
+
+func TestBinaryEncodeUndefinedValue(t *testing.T) {
+	data := NewPhpSession().SetUndefined("undef").Set("inteiro", 34)
+
+	if result, err := EncodeBinary(data); err != nil {
+		t.Errorf("Can not encode binary session %#v \n", err)
+	} else if result != "\x85undef\x07inteiroi:34;" {
+		t.Errorf("Undefined value was encoded incorrectly %q \n", result)
+	}
+}
+
+func TestBinaryEncodeDropLongName(t *testing.T) {
+	data := NewPhpSession().Set(strings.Repeat("a", BINARY_MAX_NAME_LENGTH+1), 1).Set("a|b", 2)
+
+	encoder := NewPhpBinaryEncoder(data)
+	encoder.SetDropInvalidNames(true)
+	if result, err := encoder.Encode(); err != nil {
+		t.Errorf("Can not encode binary session %#v \n", err)
+	} else if result != "\x03a|bi:2;" {
+		t.Errorf("Long name was not dropped %q \n", result)
+	}
+}
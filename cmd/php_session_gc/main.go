@@ -0,0 +1,44 @@
+// Command php_session_gc deletes expired sessions of PHP files save handler.
+//
+// Usage:
+//
+//	php_session_gc -save-path "2;/var/lib/php/sessions" -maxlifetime 1440 [-dry-run]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/solidwall/php_session_decoder/files"
+)
+
+func main() {
+	savePath := flag.String("save-path", "", "session.save_path in [N;[MODE;]]/path syntax")
+	maxLifetime := flag.Int("maxlifetime", 1440, "session.gc_maxlifetime in seconds")
+	dryRun := flag.Bool("dry-run", false, "report expired sessions without deleting them")
+	flag.Parse()
+
+	if *maxLifetime <= 0 {
+		fmt.Fprintln(os.Stderr, "php_session_gc: -maxlifetime has to be positive")
+		os.Exit(2)
+	}
+
+	store, err := files.NewStore(*savePath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	stats, err := store.GC(files.GCOptions{
+		MaxLifetime: time.Duration(*maxLifetime) * time.Second,
+		DryRun:      *dryRun,
+	})
+	fmt.Printf("scanned: %d, expired: %d, deleted: %d, locked: %d, failed: %d\n",
+		stats.Scanned, stats.Expired, stats.Deleted, stats.Locked, stats.Failed)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -1,7 +1,11 @@
-package main
+package php_session_decoder
 
-import "github.com/solidwall/php_session_decoder/php_serialize"
+const (
+	SEPARATOR_VALUE_NAME rune = '|'
+	MARKER_UNDEFINED     rune = '!'
+)
 
-const SEPARATOR_VALUE_NAME rune = '|'
-
-type PhpSession map[string]php_serialize.PhpValue
+const (
+	BINARY_MAX_NAME_LENGTH int  = 127
+	BINARY_UNDEFINED_FLAG  byte = 0x80
+)
@@ -1,66 +1,109 @@
-package main
+package php_session_decoder
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"strings"
 
 	"github.com/solidwall/php_session_decoder/php_serialize"
 )
 
+func Decode(phpSession string) (*PhpSession, error) {
+	decoder := NewPhpDecoder(phpSession)
+	return decoder.Decode()
+}
+
 type PhpDecoder struct {
-	source  *strings.Reader
-	decoder *php_serialize.UnSerializer
+	source     *sessionReader
+	decoder    *php_serialize.UnSerializer
+	decodeFunc php_serialize.SerializedDecodeFunc
+	// strict makes Decode fail on trailing data without separator, PHP ignores it
+	strict bool
 }
 
 func NewPhpDecoder(phpSession string) *PhpDecoder {
+	return NewPhpDecoderFromReader(strings.NewReader(phpSession))
+}
+
+// NewPhpDecoderFromReader creates decoder which reads session data directly from r.
+func NewPhpDecoderFromReader(r io.Reader) *PhpDecoder {
 	decoder := &PhpDecoder{
-		source:  strings.NewReader(phpSession),
+		source:  newSessionReader(r),
 		decoder: php_serialize.NewUnSerializer(""),
 	}
-	decoder.decoder.SetReader(decoder.source)
+	decoder.decoder.SetRuneReader(decoder.source)
 	return decoder
 }
 
 func (pd *PhpDecoder) SetSerializedDecodeFunc(f php_serialize.SerializedDecodeFunc) {
+	pd.decodeFunc = f
 	pd.decoder.SetSerializedDecodeFunc(f)
 }
 
-func (pd *PhpDecoder) Decode() (PhpSession, error) {
+func (pd *PhpDecoder) Decode() (*PhpSession, error) {
+	return pd.decode(nil)
+}
+
+// DecodeKeys decodes only given variables, values of others are skipped
+// and decoding stops as soon as all of them are found.
+func (pd *PhpDecoder) DecodeKeys(names ...string) (*PhpSession, error) {
+	return pd.decode(newNameFilter(names))
+}
+
+func (pd *PhpDecoder) decode(filter nameFilter) (*PhpSession, error) {
 	var (
 		name  string
 		err   error
 		value php_serialize.PhpValue
 	)
-	res := make(PhpSession)
+	res := NewPhpSession()
+	res.decodeFunc = pd.decodeFunc
 
 	for {
 		if name, err = pd.readName(); err != nil {
 			break
 		}
-		if value, err = pd.decoder.Decode(); err != nil {
+		if strings.HasPrefix(name, string(MARKER_UNDEFINED)) {
+			if filter.match(name[1:]) {
+				res.setDecoded(name[1:], PhpUndefined{}, "")
+			}
+		} else if !filter.match(name) {
+			if err = pd.decoder.Skip(); err != nil {
+				break
+			}
+		} else {
+			pd.source.startRecord()
+			if value, err = pd.decoder.Decode(); err != nil {
+				break
+			}
+			res.setDecoded(name, value, pd.source.stopRecord())
+		}
+		if filter.done(res) {
 			break
 		}
-		res[name] = value
 	}
 
 	if err == io.EOF {
 		err = nil
+		if pd.strict && name != "" {
+			err = fmt.Errorf("php_session: missing value for %q", name)
+		}
 	}
 	return res, err
 }
 
 func (pd *PhpDecoder) readName() (string, error) {
 	var (
-		token rune
+		token byte
 		err   error
 	)
 	buf := bytes.NewBuffer([]byte{})
 	for {
-		if token, _, err = pd.source.ReadRune(); err != nil || token == SEPARATOR_VALUE_NAME {
+		if token, err = pd.source.ReadByte(); err != nil || token == byte(SEPARATOR_VALUE_NAME) {
 			break
 		} else {
-			buf.WriteRune(token)
+			buf.WriteByte(token)
 		}
 	}
 	return buf.String(), err
@@ -1,9 +1,11 @@
-package main
+package php_session_decoder
 
 import (
 	"encoding/json"
 	"io/ioutil"
+	"os"
 	"testing"
+	"testing/iotest"
 
 	"github.com/solidwall/php_session_decoder/php_serialize"
 )
@@ -13,7 +15,7 @@ func TestDecodeBooleanValue(t *testing.T) {
 	if result, err := decoder.Decode(); err != nil {
 		t.Errorf("Can not decode boolens value %#v \n", err)
 	} else {
-		if v, ok := (result)["login_ok"]; !ok {
+		if v, ok := result.Get("login_ok"); !ok {
 			t.Errorf("Boolean value was not decoded \n")
 		} else if v != true {
 			t.Errorf("Boolean value was incorrectly decoded \n")
@@ -26,7 +28,7 @@ func TestDecodeIntValue(t *testing.T) {
 	if result, err := decoder.Decode(); err != nil {
 		t.Errorf("Can not decode int value %#v \n", err)
 	} else {
-		if v, ok := (result)["inteiro"]; !ok {
+		if v, ok := result.Get("inteiro"); !ok {
 			t.Errorf("Int value was not decoded \n")
 		} else if v != 34 {
 			t.Errorf("Int value was decoded incorrectly: %v\n", v)
@@ -39,7 +41,7 @@ func TestDecodeBooleanAndIntValue(t *testing.T) {
 	if result, err := decoder.Decode(); err != nil {
 		t.Errorf("Can not decode int value %#v \n", err)
 	} else {
-		if v, ok := (result)["inteiro"]; !ok {
+		if v, ok := result.Get("inteiro"); !ok {
 			t.Errorf("Int value was not decoded \n")
 		} else if v != 34 {
 			t.Errorf("Int value was decoded incorrectly: %v\n", v)
@@ -52,7 +54,7 @@ func TestDecodeFloatValue(t *testing.T) {
 	if result, err := decoder.Decode(); err != nil {
 		t.Errorf("Can not decode float value %#v \n", err)
 	} else {
-		if v, ok := (result)["float_test"]; !ok {
+		if v, ok := result.Get("float_test"); !ok {
 			t.Errorf("Float value was not decoded \n")
 		} else if v != 34.4679999999 {
 			t.Errorf("Float value was decoded incorrectly: %v\n", v)
@@ -65,7 +67,7 @@ func TestDecodeStringValue(t *testing.T) {
 	if result, err := decoder.Decode(); err != nil {
 		t.Errorf("Can not decode string value %#v \n", err)
 	} else {
-		if v, ok := (result)["name"]; !ok {
+		if v, ok := result.Get("name"); !ok {
 			t.Errorf("String value was not decoded \n")
 		} else if v != "some text" {
 			t.Errorf("String value was decoded incorrectly: %v\n", v)
@@ -78,7 +80,7 @@ func TestDecodeArrayValue(t *testing.T) {
 	if result, err := decoder.Decode(); err != nil {
 		t.Errorf("Can not decode array value %#v \n", err)
 	} else {
-		if v, ok := (result)["arr"]; !ok {
+		if v, ok := result.Get("arr"); !ok {
 			t.Errorf("Array value was not decoded \n")
 		} else if arrValue, ok := v.(php_serialize.PhpArray); ok != true {
 			t.Errorf("Array value was decoded incorrectly: %#v \n", v)
@@ -97,7 +99,7 @@ func TestDecodeObjectValue(t *testing.T) {
 	if result, err := decoder.Decode(); err != nil {
 		t.Errorf("Can not decode object value %#v \n", err)
 	} else {
-		if v, ok := (result)["obj"]; !ok {
+		if v, ok := result.Get("obj"); !ok {
 			t.Errorf("Object value was not decoded \n")
 		} else if objValue, ok := v.(*php_serialize.PhpObject); ok != true {
 			t.Errorf("Object value was decoded incorrectly: %#v \n", v)
@@ -118,7 +120,7 @@ func TestDecodeComplexArrayValue(t *testing.T) {
 	if result, err := decoder.Decode(); err != nil {
 		t.Errorf("Can not decode array value %#v \n", err)
 	} else {
-		if v, ok := (result)["arr2"]; !ok {
+		if v, ok := result.Get("arr2"); !ok {
 			t.Errorf("Array value was not decoded \n")
 		} else if arrValue, ok := v.(php_serialize.PhpArray); ok != true {
 			t.Errorf("Array value was decoded incorrectly: %#v \n", v)
@@ -141,7 +143,7 @@ func TestDecodeMultidimensionalArrayValue(t *testing.T) {
 	if result, err := decoder.Decode(); err != nil {
 		t.Errorf("Can not decode array value %#v \n", err)
 	} else {
-		if v, ok := (result)["arr3"]; !ok {
+		if v, ok := result.Get("arr3"); !ok {
 			t.Errorf("Array value was not decoded \n")
 		} else if arrValue, ok := v.(php_serialize.PhpArray); ok != true {
 			t.Errorf("Array value was decoded incorrectly: %#v \n", v)
@@ -168,7 +170,7 @@ func TestDecodeMultipleArraysWithoutSemicolons(t *testing.T) {
 	if result, err := decoder.Decode(); err != nil {
 		t.Errorf("Can not decode array value %#v \n", err)
 	} else {
-		if v, ok := result["array1"]; !ok {
+		if v, ok := result.Get("array1"); !ok {
 			t.Errorf("First array was not decoded \n")
 		} else if arrValue, ok := v.(php_serialize.PhpArray); ok != true {
 			t.Errorf("Array value was decoded incorrectly: %#v \n", v)
@@ -176,7 +178,7 @@ func TestDecodeMultipleArraysWithoutSemicolons(t *testing.T) {
 			t.Errorf("Array value was decoded incorrectly: %#v\n", v)
 		}
 
-		if v, ok := result["array2"]; !ok {
+		if v, ok := result.Get("array2"); !ok {
 			t.Errorf("Second array was not decoded \n")
 		} else if arrValue, ok := v.(php_serialize.PhpArray); ok != true {
 			t.Errorf("Array value was decoded incorrectly: %#v \n", v)
@@ -191,7 +193,7 @@ func TestDecodeSerializableObjectValueNoFunc(t *testing.T) {
 	if result, err := decoder.Decode(); err != nil {
 		t.Errorf("Can not decode object value %#v \n", err)
 	} else {
-		if v, ok := (result)["obj"]; !ok {
+		if v, ok := result.Get("obj"); !ok {
 			t.Errorf("Object value was not decoded \n")
 		} else if objValue, ok := v.(*php_serialize.PhpObjectSerialized); ok != true {
 			t.Errorf("Object value was decoded incorrectly: %#v \n", v)
@@ -209,7 +211,7 @@ func TestDecodeSerializableObjectValue(t *testing.T) {
 	if result, err := decoder.Decode(); err != nil {
 		t.Errorf("Can not decode object value %#v \n", err)
 	} else {
-		if v, ok := (result)["object"]; !ok {
+		if v, ok := result.Get("object"); !ok {
 			t.Errorf("Object value was not decoded \n")
 		} else if objValue, ok := v.(*php_serialize.PhpObjectSerialized); ok != true {
 			t.Errorf("Object value was decoded incorrectly: %#v \n", v)
@@ -242,7 +244,7 @@ func TestDecodeSerializableObjectFoo(t *testing.T) {
 	if result, err := decoder.Decode(); err != nil {
 		t.Errorf("Can not decode object value %#v \n", err)
 	} else {
-		if v, ok := (result)["foo"]; !ok {
+		if v, ok := result.Get("foo"); !ok {
 			t.Errorf("Object value was not decoded \n")
 		} else if objValue, ok := v.(*php_serialize.PhpObjectSerialized); ok != true {
 			t.Errorf("Object value was decoded incorrectly: %#v \n", v)
@@ -269,7 +271,7 @@ func TestDecodeSerializableObjectBar(t *testing.T) {
 	if result, err := decoder.Decode(); err != nil {
 		t.Errorf("Can not decode object value %#v \n", err)
 	} else {
-		if v, ok := (result)["bar"]; !ok {
+		if v, ok := result.Get("bar"); !ok {
 			t.Errorf("Object value was not decoded \n")
 		} else if objValue, ok := v.(*php_serialize.PhpObjectSerialized); ok != true {
 			t.Errorf("Object value was decoded incorrectly: %#v \n", v)
@@ -297,9 +299,83 @@ func TestDecodeRealData(t *testing.T) {
 	} else {
 		rootKeys := []string{"product_last_viewed", "core", "customer", "checkout", "store_default", "catalog", "object"}
 		for _, v := range rootKeys {
-			if _, ok := result[v]; !ok {
+			if _, ok := result.Get(v); !ok {
 				t.Errorf("Can not find %v key\n", v)
 			}
 		}
 	}
 }
+
+func TestDecodeFunc(t *testing.T) {
+	if result, err := Decode("login_ok|b:1;inteiro|i:34;"); err != nil {
+		t.Errorf("Can not decode session %#v \n", err)
+	} else if v, _ := result.Get("login_ok"); result.Len() != 2 || v != true {
+		t.Errorf("Session was decoded incorrectly: %#v\n", result)
+	} else if v, _ := result.Get("inteiro"); v != 34 {
+		t.Errorf("Session was decoded incorrectly: %#v\n", result)
+	}
+}
+
+func TestDecodeRealDataRoundTrip(t *testing.T) {
+	testData, _ := ioutil.ReadFile("./data/test.session")
+	decoder := NewPhpDecoder(string(testData))
+	decoder.SetSerializedDecodeFunc(php_serialize.SerializedDecodeFunc(php_serialize.UnSerialize))
+	if result, err := decoder.Decode(); err != nil {
+		t.Errorf("Can not decode array value %#v \n", err)
+	} else if encoded, err := Encode(result); err != nil {
+		t.Errorf("Can not encode session %#v \n", err)
+	} else if encoded != string(testData) {
+		t.Errorf("Unmodified session was encoded incorrectly\n")
+	}
+}
+
+func TestDecodeUndefinedValue(t *testing.T) {
+	decoder := NewPhpDecoder("!undef|nil|N;!last|")
+	if result, err := decoder.Decode(); err != nil {
+		t.Errorf("Can not decode undefined value %#v \n", err)
+	} else {
+		if !result.IsUndefined("undef") || !result.IsUndefined("last") {
+			t.Errorf("Undefined value was decoded incorrectly: %#v\n", result)
+		}
+		if v, ok := result.Get("nil"); !ok || v != nil || result.IsUndefined("nil") {
+			t.Errorf("Null value was decoded incorrectly: %#v\n", result)
+		}
+	}
+}
+
+func TestDecodeFromReader(t *testing.T) {
+	testData, _ := ioutil.ReadFile("./data/test.session")
+	file, err := os.Open("./data/test.session")
+	if err != nil {
+		t.Fatalf("Can not open session file %#v \n", err)
+	}
+	defer file.Close()
+
+	decoder := NewPhpDecoderFromReader(iotest.OneByteReader(file))
+	if result, err := decoder.Decode(); err != nil {
+		t.Errorf("Can not decode session from reader %#v \n", err)
+	} else if encoded, err := Encode(result); err != nil {
+		t.Errorf("Can not encode session %#v \n", err)
+	} else if encoded != string(testData) {
+		t.Errorf("Session from reader was decoded incorrectly\n")
+	}
+}
+
+func TestDecodeKeys(t *testing.T) {
+	testData, _ := ioutil.ReadFile("./data/test.session")
+	decoder := NewPhpDecoder(string(testData))
+	if result, err := decoder.DecodeKeys("customer", "object", "unknown"); err != nil {
+		t.Errorf("Can not decode selected keys %#v \n", err)
+	} else if names := result.Names(); len(names) != 2 || names[0] != "customer" || names[1] != "object" {
+		t.Errorf("Selected keys were decoded incorrectly: %v\n", names)
+	}
+}
+
+func TestDecodeKeysStopsWhenFound(t *testing.T) {
+	decoder := NewPhpDecoder("skip|a:1:{i:0;O:3:\"Foo\":0:{}}!undef|user_id|i:42;broken|x")
+	if result, err := decoder.DecodeKeys("user_id", "undef"); err != nil {
+		t.Errorf("Can not decode selected keys %#v \n", err)
+	} else if v, ok := result.Get("user_id"); !ok || v != 42 || result.Len() != 2 || !result.IsUndefined("undef") {
+		t.Errorf("Selected keys were decoded incorrectly: %#v\n", result)
+	}
+}
@@ -1,2 +1,2 @@
-// Package main provides possibility to decode/encode php session data in php_binary format.
-package main
+// Package php_session_decoder provides possibility to decode/encode php session data in php, php_binary and php_serialize formats.
+package php_session_decoder
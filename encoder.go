@@ -1,18 +1,25 @@
-package main
+package php_session_decoder
 
 import (
 	"bytes"
 	"fmt"
+	"io"
 
 	"github.com/solidwall/php_session_decoder/php_serialize"
 )
 
+func Encode(data *PhpSession) (string, error) {
+	encoder := NewPhpEncoder(data)
+	return encoder.Encode()
+}
+
 type PhpEncoder struct {
-	data    PhpSession
-	encoder *php_serialize.Serializer
+	data             *PhpSession
+	encoder          *php_serialize.Serializer
+	dropInvalidNames bool
 }
 
-func NewPhpEncoder(data PhpSession) *PhpEncoder {
+func NewPhpEncoder(data *PhpSession) *PhpEncoder {
 	return &PhpEncoder{
 		data:    data,
 		encoder: php_serialize.NewSerializer(),
@@ -23,25 +30,57 @@ func (pe *PhpEncoder) SetSerializedEncodeFunc(f php_serialize.SerializedEncodeFu
 	pe.encoder.SetSerializedEncodeFunc(f)
 }
 
+// SetDropInvalidNames makes Encode skip variables which names can't be written
+// by the handler, like session_encode does, instead of returning ErrInvalidName.
+func (pe *PhpEncoder) SetDropInvalidNames(drop bool) {
+	pe.dropInvalidNames = drop
+}
+
 func (pe *PhpEncoder) Encode() (string, error) {
+	buf := bytes.NewBuffer([]byte{})
+	err := pe.encode(buf)
+	return buf.String(), err
+}
+
+// EncodeTo writes encoded session to w without building it in memory first.
+func (pe *PhpEncoder) EncodeTo(w io.Writer) error {
+	return encodeTo(w, pe.encode)
+}
+
+// AppendEncode appends encoded session to dst and returns the extended buffer.
+func (pe *PhpEncoder) AppendEncode(dst []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(dst)
+	err := pe.encode(buf)
+	return buf.Bytes(), err
+}
+
+func (pe *PhpEncoder) encode(buf php_serialize.Writer) error {
 	if pe.data == nil {
-		return "", nil
+		return nil
 	}
-	var (
-		err error
-		val string
-	)
-	buf := bytes.NewBuffer([]byte{})
+	var err error
 
-	for k, v := range pe.data {
+	for _, k := range pe.data.names {
+		if err = validatePhpName(k); err != nil {
+			if pe.dropInvalidNames {
+				err = nil
+				continue
+			}
+			break
+		}
+		if pe.data.IsUndefined(k) {
+			buf.WriteRune(MARKER_UNDEFINED)
+			buf.WriteString(k)
+			buf.WriteRune(SEPARATOR_VALUE_NAME)
+			continue
+		}
 		buf.WriteString(k)
 		buf.WriteRune(SEPARATOR_VALUE_NAME)
-		if val, err = pe.encoder.Encode(v); err != nil {
+		if err = pe.data.encodeValue(buf, k, pe.encoder); err != nil {
 			err = fmt.Errorf("php_session: error during encode value for %q: %v", k, err)
 			break
 		}
-		buf.WriteString(val)
 	}
 
-	return buf.String(), err
+	return err
 }
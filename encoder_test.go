@@ -1,7 +1,10 @@
-package main
+package php_session_decoder
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"io"
 	"strings"
 	"testing"
 
@@ -9,9 +12,7 @@ import (
 )
 
 func TestEncodeBooleanValue(t *testing.T) {
-	data := PhpSession{
-		"login_ok": true,
-	}
+	data := NewPhpSession().Set("login_ok", true)
 
 	encoder := NewPhpEncoder(data)
 	if result, err := encoder.Encode(); err != nil {
@@ -24,9 +25,7 @@ func TestEncodeBooleanValue(t *testing.T) {
 }
 
 func TestEncodeIntValue(t *testing.T) {
-	data := PhpSession{
-		"inteiro": 34,
-	}
+	data := NewPhpSession().Set("inteiro", 34)
 
 	encoder := NewPhpEncoder(data)
 	if result, err := encoder.Encode(); err != nil {
@@ -39,9 +38,7 @@ func TestEncodeIntValue(t *testing.T) {
 }
 
 func TestEncodeFloatValue(t *testing.T) {
-	data := PhpSession{
-		"float_test": 34.4679999999,
-	}
+	data := NewPhpSession().Set("float_test", 34.4679999999)
 
 	encoder := NewPhpEncoder(data)
 	if result, err := encoder.Encode(); err != nil {
@@ -55,9 +52,7 @@ func TestEncodeFloatValue(t *testing.T) {
 }
 
 func TestEncodeStringValue(t *testing.T) {
-	data := PhpSession{
-		"name": "some text",
-	}
+	data := NewPhpSession().Set("name", "some text")
 
 	encoder := NewPhpEncoder(data)
 	if result, err := encoder.Encode(); err != nil {
@@ -70,15 +65,13 @@ func TestEncodeStringValue(t *testing.T) {
 }
 
 func TestEncodeArrayValue(t *testing.T) {
-	data := PhpSession{
-		"arr": php_serialize.PhpArray{
-			// Zero element
-			//php_serialize.PhpValue(0): 5,
-			0:       5,
-			"test":  true,
-			"test2": nil,
-		},
-	}
+	data := NewPhpSession().Set("arr", php_serialize.PhpArray{
+		// Zero element
+		//php_serialize.PhpValue(0): 5,
+		0:       5,
+		"test":  true,
+		"test2": nil,
+	})
 
 	encoder := NewPhpEncoder(data)
 	if result, err := encoder.Encode(); err != nil {
@@ -95,9 +88,7 @@ func TestEncodeObjectValue(t *testing.T) {
 	obj.SetPublic("a", 5)
 	obj.SetProtected("c", 8)
 	obj.SetPrivate("b", "priv")
-	data := PhpSession{
-		"obj": obj,
-	}
+	data := NewPhpSession().Set("obj", obj)
 
 	encoder := NewPhpEncoder(data)
 	if result, err := encoder.Encode(); err != nil {
@@ -112,9 +103,7 @@ func TestEncodeObjectValue(t *testing.T) {
 func TestEncodeSerializableObjectValueNoFunc(t *testing.T) {
 	obj := php_serialize.NewPhpObjectSerialized("TestObject")
 	obj.SetData("a:3:{s:1:\"a\";i:5;s:1:\"b\";s:4:\"priv\";s:1:\"c\";i:8;}")
-	data := PhpSession{
-		"obj": obj,
-	}
+	data := NewPhpSession().Set("obj", obj)
 
 	encoder := NewPhpEncoder(data)
 	if result, err := encoder.Encode(); err != nil {
@@ -134,9 +123,7 @@ func TestEncodeSerializableObjectValue(t *testing.T) {
 	}
 	obj := php_serialize.NewPhpObjectSerialized("TestObject")
 	obj.SetValue(php_serialize.PhpValue(arr))
-	data := PhpSession{
-		"obj": obj,
-	}
+	data := NewPhpSession().Set("obj", obj)
 
 	encoder := NewPhpEncoder(data)
 	encoder.SetSerializedEncodeFunc(php_serialize.SerializedEncodeFunc(php_serialize.Serialize))
@@ -163,9 +150,7 @@ func TestEncodeSerializableObjectValueJSON(t *testing.T) {
 
 	obj := php_serialize.NewPhpObjectSerialized("Bar")
 	obj.SetValue(map[string]string{"public": "public"})
-	data := PhpSession{
-		"bar": obj,
-	}
+	data := NewPhpSession().Set("bar", obj)
 
 	encoder := NewPhpEncoder(data)
 	encoder.SetSerializedEncodeFunc(f)
@@ -177,3 +162,124 @@ func TestEncodeSerializableObjectValueJSON(t *testing.T) {
 		}
 	}
 }
+
+func TestEncodeFunc(t *testing.T) {
+	if result, err := Encode(NewPhpSession().Set("inteiro", 34)); err != nil {
+		t.Errorf("Can not encode session %#v \n", err)
+	} else if result != "inteiro|i:34;" {
+		t.Errorf("Session was encoded incorrectly %v \n", result)
+	}
+}
+
+func TestEncodeUndefinedValue(t *testing.T) {
+	data := NewPhpSession().SetUndefined("undef").Set("nil", nil)
+
+	encoder := NewPhpEncoder(data)
+	if result, err := encoder.Encode(); err != nil {
+		t.Errorf("Can not encode undefined value %#v \n", err)
+	} else if result != "!undef|nil|N;" {
+		t.Errorf("Undefined value was encoded incorrectly %v \n", result)
+	}
+}
+
+func TestEncodeInvalidName(t *testing.T) {
+	for _, name := range []string{"a|b", "!name"} {
+		data := NewPhpSession().Set("inteiro", 34).Set(name, 1)
+		if _, err := Encode(data); !errors.Is(err, ErrInvalidName) || !strings.Contains(err.Error(), name) {
+			t.Errorf("Name %q should not be encoded, have got: %v\n", name, err)
+		}
+	}
+}
+
+func TestEncodeDropInvalidName(t *testing.T) {
+	data := NewPhpSession().Set("a|b", 1).Set("inteiro", 34).Set("!name", 2)
+
+	encoder := NewPhpEncoder(data)
+	encoder.SetDropInvalidNames(true)
+	if result, err := encoder.Encode(); err != nil {
+		t.Errorf("Can not encode session %#v \n", err)
+	} else if result != "inteiro|i:34;" {
+		t.Errorf("Invalid names were not dropped %v \n", result)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestEncodeTo(t *testing.T) {
+	var buffer bytes.Buffer
+	data := NewPhpSession().Set("login_ok", true).Set("name", "some text")
+
+	encoder := NewPhpEncoder(data)
+	if err := encoder.EncodeTo(io.MultiWriter(&buffer)); err != nil {
+		t.Errorf("Can not encode session to writer %#v \n", err)
+	} else if buffer.String() != "login_ok|b:1;name|s:9:\"some text\";" {
+		t.Errorf("Session was encoded to writer incorrectly %v \n", buffer.String())
+	}
+
+	if err := encoder.EncodeTo(failingWriter{}); err == nil {
+		t.Errorf("Error of writer should be returned\n")
+	}
+}
+
+// limitWriter accepts limit bytes and fails after that, it implements php_serialize.Writer.
+type limitWriter struct {
+	bytes.Buffer
+	limit int
+}
+
+func (lw *limitWriter) Write(p []byte) (int, error) {
+	if lw.Len()+len(p) > lw.limit {
+		return 0, errors.New("write failed")
+	}
+	return lw.Buffer.Write(p)
+}
+
+func (lw *limitWriter) WriteByte(c byte) error {
+	_, err := lw.Write([]byte{c})
+	return err
+}
+
+func (lw *limitWriter) WriteString(s string) (int, error) {
+	return lw.Write([]byte(s))
+}
+
+func (lw *limitWriter) WriteRune(r rune) (int, error) {
+	return lw.Write([]byte(string(r)))
+}
+
+func TestEncodeToWriteErrors(t *testing.T) {
+	data := NewPhpSession().Set("a", 1).SetUndefined("u").Set("name", "text")
+	encoders := map[string]func(w io.Writer) error{
+		"php":           NewPhpEncoder(data).EncodeTo,
+		"php_binary":    NewPhpBinaryEncoder(data).EncodeTo,
+		"php_serialize": NewPhpSerializeEncoder(NewPhpSession().Set("a", 1).Set("name", "text")).EncodeTo,
+	}
+
+	for format, encodeTo := range encoders {
+		full := &limitWriter{limit: 1 << 10}
+		if err := encodeTo(full); err != nil {
+			t.Fatalf("Can not encode session in %s format: %v\n", format, err)
+		}
+		// every write has to be checked, wherever the writer fails
+		for limit := 0; limit < full.Len(); limit++ {
+			if err := encodeTo(&limitWriter{limit: limit}); err == nil {
+				t.Errorf("Error of writer failed after %d bytes was not returned in %s format\n", limit, format)
+			}
+		}
+	}
+}
+
+func TestAppendEncode(t *testing.T) {
+	data := NewPhpSession().Set("login_ok", true)
+
+	encoder := NewPhpEncoder(data)
+	if result, err := encoder.AppendEncode([]byte("inteiro|i:34;")); err != nil {
+		t.Errorf("Can not append encoded session %#v \n", err)
+	} else if string(result) != "inteiro|i:34;login_ok|b:1;" {
+		t.Errorf("Session was appended incorrectly %v \n", string(result))
+	}
+}
@@ -0,0 +1,2 @@
+// Package files provides session store compatible with PHP files save handler.
+package files
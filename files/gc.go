@@ -0,0 +1,134 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type GCOptions struct {
+	// MaxLifetime is session.gc_maxlifetime, sessions modified earlier are expired.
+	MaxLifetime time.Duration
+	// DryRun only counts expired sessions without deleting them.
+	DryRun bool
+}
+
+type GCStats struct {
+	// Scanned is the number of session files found.
+	Scanned int
+	// Expired is the number of session files older than MaxLifetime.
+	Expired int
+	// Deleted is the number of deleted files, in dry run mode the number of files which would be deleted.
+	Deleted int
+	// Locked is the number of expired files skipped because they are locked.
+	Locked int
+	// Failed is the number of files which can't be checked or deleted.
+	Failed int
+}
+
+// GC deletes sessions not modified for MaxLifetime in the save path and its hashed subdirectories,
+// like PHP files handler does. Locked sessions are skipped. Errors don't stop the scan,
+// the first of them is returned together with statistics. MaxLifetime has to be positive,
+// otherwise all sessions would be expired.
+func (s *Store) GC(options GCOptions) (GCStats, error) {
+	if options.MaxLifetime <= 0 {
+		return GCStats{}, fmt.Errorf("files: invalid MaxLifetime %v, it has to be positive", options.MaxLifetime)
+	}
+
+	var (
+		stats   GCStats
+		lastErr error
+	)
+	saveError := func(err error) {
+		stats.Failed++
+		if lastErr == nil {
+			lastErr = err
+		}
+	}
+	expired := time.Now().Add(-options.MaxLifetime)
+
+	err := filepath.WalkDir(s.path, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			saveError(err)
+			return nil
+		}
+		if entry.IsDir() {
+			if name != s.path && s.level(name) > s.depth {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() || !strings.HasPrefix(entry.Name(), FILE_PREFIX) || s.level(name) != s.depth+1 {
+			return nil
+		}
+
+		stats.Scanned++
+		info, err := entry.Info()
+		if err != nil {
+			saveError(err)
+			return nil
+		}
+		if !info.ModTime().Before(expired) {
+			return nil
+		}
+
+		stats.Expired++
+		if deleted, err := s.deleteExpired(name, expired, options.DryRun); err == errLocked {
+			stats.Locked++
+		} else if err != nil {
+			saveError(err)
+		} else if deleted {
+			stats.Deleted++
+		}
+		return nil
+	})
+	if err != nil && lastErr == nil {
+		lastErr = err
+	}
+	return stats, lastErr
+}
+
+// level returns the number of path elements of name below the save path.
+func (s *Store) level(name string) int {
+	rel, err := filepath.Rel(s.path, name)
+	if err != nil {
+		return -1
+	}
+	return len(strings.Split(rel, string(filepath.Separator)))
+}
+
+var errLocked = errors.New("files: session file is locked")
+
+// deleteExpired deletes the file unless it is locked or was modified after it has been found.
+func (s *Store) deleteExpired(name string, expired time.Time, dryRun bool) (bool, error) {
+	file, err := os.OpenFile(name, os.O_RDWR, 0)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	if locked, err := tryLockFile(file); err != nil {
+		return false, err
+	} else if !locked {
+		return false, errLocked
+	}
+	defer unlockFile(file)
+
+	info, err := file.Stat()
+	if err != nil {
+		return false, err
+	}
+	if !info.ModTime().Before(expired) {
+		return false, nil
+	}
+	if dryRun {
+		return true, nil
+	}
+	return true, os.Remove(name)
+}
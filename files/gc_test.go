@@ -0,0 +1,75 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGC(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-2 * time.Hour)
+	sessions := map[string]bool{
+		filepath.Join(dir, "a", "sess_abc"):   true,
+		filepath.Join(dir, "a", "sess_alive"): false,
+		filepath.Join(dir, "b", "sess_bcd"):   true,
+		filepath.Join(dir, "b", "other_file"): true,
+		filepath.Join(dir, "sess_toplevel"):   true,
+	}
+	for name, expired := range sessions {
+		os.MkdirAll(filepath.Dir(name), 0700)
+		if err := os.WriteFile(name, []byte("a|i:1;"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if expired {
+			os.Chtimes(name, old, old)
+		}
+	}
+	store, _ := NewStore("1;" + dir)
+
+	locked, err := store.Open("bcd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer locked.Close()
+	os.Chtimes(filepath.Join(dir, "b", "sess_bcd"), old, old)
+
+	for _, maxLifetime := range []time.Duration{0, -time.Hour} {
+		if _, err := store.GC(GCOptions{MaxLifetime: maxLifetime}); err == nil {
+			t.Errorf("GC with MaxLifetime %v should fail\n", maxLifetime)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a", "sess_alive")); err != nil {
+		t.Errorf("GC with invalid MaxLifetime has deleted session: %v\n", err)
+	}
+
+	stats, err := store.GC(GCOptions{MaxLifetime: time.Hour, DryRun: true})
+	if err != nil {
+		t.Errorf("Error during dry run: %v\n", err)
+	} else if stats != (GCStats{Scanned: 3, Expired: 2, Deleted: 1, Locked: 1}) {
+		t.Errorf("Dry run statistics is incorrect: %+v\n", stats)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a", "sess_abc")); err != nil {
+		t.Errorf("Dry run has deleted session: %v\n", err)
+	}
+
+	stats, err = store.GC(GCOptions{MaxLifetime: time.Hour})
+	if err != nil {
+		t.Errorf("Error during GC: %v\n", err)
+	} else if stats != (GCStats{Scanned: 3, Expired: 2, Deleted: 1, Locked: 1}) {
+		t.Errorf("GC statistics is incorrect: %+v\n", stats)
+	}
+
+	for name, deleted := range map[string]bool{
+		filepath.Join(dir, "a", "sess_abc"):   true,
+		filepath.Join(dir, "a", "sess_alive"): false,
+		filepath.Join(dir, "b", "sess_bcd"):   false,
+		filepath.Join(dir, "b", "other_file"): false,
+		filepath.Join(dir, "sess_toplevel"):   false,
+	} {
+		if _, err := os.Stat(name); os.IsNotExist(err) != deleted {
+			t.Errorf("File %s was handled incorrectly, expected to be deleted: %v\n", name, deleted)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package files
+
+import (
+	"os"
+)
+
+// lockSupported is false here, NewStore returns ErrLockNotSupported.
+const lockSupported = false
+
+func lockFile(file *os.File) error {
+	return ErrLockNotSupported
+}
+
+func tryLockFile(file *os.File) (bool, error) {
+	return false, ErrLockNotSupported
+}
+
+func unlockFile(file *os.File) error {
+	return ErrLockNotSupported
+}
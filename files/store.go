@@ -0,0 +1,247 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/solidwall/php_session_decoder"
+)
+
+const (
+	FILE_PREFIX       = "sess_"
+	FILE_MODE_DEFAULT = os.FileMode(0600)
+)
+
+// ErrLockNotSupported is returned by NewStore on platforms without flock, such as Windows.
+var ErrLockNotSupported = errors.New("files: flock is not supported on this platform")
+
+// Store reads and writes sess_<id> files in session.save_path. Every access
+// takes an exclusive flock on the file, the same lock PHP takes.
+type Store struct {
+	path  string
+	depth int
+	mode  os.FileMode
+}
+
+var (
+	_ php_session_decoder.Store        = (*Store)(nil)
+	_ php_session_decoder.HandleLocker = (*Store)(nil)
+	_ php_session_decoder.Toucher      = (*Store)(nil)
+)
+
+// NewStore creates store for session.save_path in `[N;[MODE;]]/path` syntax,
+// where N is the depth of hashed subdirectories and MODE is octal mode of new files.
+// Like PHP, it does not create subdirectories. The store takes flock locks, so it is available
+// on unix platforms only, ErrLockNotSupported is returned on the others.
+func NewStore(savePath string) (*Store, error) {
+	if !lockSupported {
+		return nil, ErrLockNotSupported
+	}
+
+	store := &Store{mode: FILE_MODE_DEFAULT}
+
+	parts := strings.SplitN(savePath, ";", 3)
+	store.path = parts[len(parts)-1]
+	if len(parts) > 1 {
+		depth, err := strconv.Atoi(parts[0])
+		if err != nil || depth < 0 {
+			return nil, fmt.Errorf("files: invalid depth %q in save path", parts[0])
+		}
+		store.depth = depth
+	}
+	if len(parts) > 2 {
+		mode, err := strconv.ParseUint(parts[1], 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("files: invalid mode %q in save path", parts[1])
+		}
+		store.mode = os.FileMode(mode)
+	}
+	if store.path == "" {
+		store.path = os.TempDir()
+	}
+	return store, nil
+}
+
+// Root returns the directory of session files without hashed subdirectories.
+func (s *Store) Root() string {
+	return s.path
+}
+
+// Depth returns the depth of hashed subdirectories.
+func (s *Store) Depth() int {
+	return s.depth
+}
+
+// Path returns the name of file for session id, the first Depth chars of id are used as subdirectories.
+func (s *Store) Path(id string) (string, error) {
+	if err := validateId(id); err != nil {
+		return "", err
+	}
+	if len(id) <= s.depth {
+		return "", fmt.Errorf("files: session id %q is too short for depth %d", id, s.depth)
+	}
+
+	parts := []string{s.path}
+	for i := 0; i < s.depth; i++ {
+		parts = append(parts, id[i:i+1])
+	}
+	parts = append(parts, FILE_PREFIX+id)
+	return filepath.Join(parts...), nil
+}
+
+// Open locks session file and creates it if it does not exist, like session_start does.
+// The lock is held until File is closed.
+func (s *Store) Open(id string) (*File, error) {
+	name, err := s.Path(id)
+	if err != nil {
+		return nil, err
+	}
+	return openFile(name, os.O_RDWR|os.O_CREATE, s.mode)
+}
+
+// LockHandle opens and locks session file like Open does, the lock is held until
+// the file is closed. Read and Write of the store wait for it meanwhile.
+func (s *Store) LockHandle(id string) (php_session_decoder.Handle, error) {
+	file, err := s.Open(id)
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// TryLockHandle is LockHandle which returns nil instead of waiting when the session is locked.
+func (s *Store) TryLockHandle(id string) (php_session_decoder.Handle, error) {
+	name, err := s.Path(id)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE, s.mode)
+	if err != nil {
+		return nil, err
+	}
+
+	locked, err := tryLockFile(file)
+	if err != nil || !locked {
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("files: unable to lock %s: %v", name, err)
+		}
+		return nil, nil
+	}
+	return &File{file: file}, nil
+}
+
+// Read waits while the session is locked, the holder of the lock reads it through the locked File.
+func (s *Store) Read(id string) (string, error) {
+	name, err := s.Path(id)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := openFile(name, os.O_RDWR, s.mode)
+	if os.IsNotExist(err) {
+		return "", php_session_decoder.ErrNotFound
+	} else if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	return file.Read()
+}
+
+// Write waits while the session is locked, the holder of the lock writes it through the locked File.
+func (s *Store) Write(id string, data string) error {
+	file, err := s.Open(id)
+	if err != nil {
+		return err
+	}
+
+	if err = file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Touch updates modification time of the session file, PHP does the same for unchanged sessions.
+func (s *Store) Touch(id string) error {
+	name, err := s.Path(id)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if err = os.Chtimes(name, now, now); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *Store) Destroy(id string) error {
+	name, err := s.Path(id)
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(name); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// File is the locked session file.
+type File struct {
+	file *os.File
+}
+
+func openFile(name string, flag int, mode os.FileMode) (*File, error) {
+	file, err := os.OpenFile(name, flag, mode)
+	if err != nil {
+		return nil, err
+	}
+	if err = lockFile(file); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("files: unable to lock %s: %v", name, err)
+	}
+	return &File{file: file}, nil
+}
+
+func (f *File) Read() (string, error) {
+	if _, err := f.file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	data, err := io.ReadAll(f.file)
+	return string(data), err
+}
+
+// Write replaces the content of the file, PHP truncates it the same way.
+func (f *File) Write(data string) error {
+	if err := f.file.Truncate(0); err != nil {
+		return err
+	}
+	_, err := f.file.WriteAt([]byte(data), 0)
+	return err
+}
+
+// Close releases the lock and closes the file.
+func (f *File) Close() error {
+	unlockFile(f.file)
+	return f.file.Close()
+}
+
+// validateId allows the same chars as PHP files handler does.
+func validateId(id string) error {
+	if id == "" {
+		return fmt.Errorf("files: empty session id")
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == ',' || c == '-') {
+			return fmt.Errorf("files: session id %q contains illegal char %q", id, c)
+		}
+	}
+	return nil
+}
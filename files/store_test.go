@@ -0,0 +1,177 @@
+package files
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/solidwall/php_session_decoder"
+)
+
+func TestNewStore(t *testing.T) {
+	cases := []struct {
+		savePath string
+		path     string
+		depth    int
+		mode     os.FileMode
+	}{
+		{"/var/lib/php/sessions", "/var/lib/php/sessions", 0, 0600},
+		{"2;/var/lib/php/sessions", "/var/lib/php/sessions", 2, 0600},
+		{"1;644;/var/lib/php/sessions", "/var/lib/php/sessions", 1, 0644},
+		{"", os.TempDir(), 0, 0600},
+	}
+
+	for _, c := range cases {
+		if store, err := NewStore(c.savePath); err != nil {
+			t.Errorf("Can not parse save path %q: %v\n", c.savePath, err)
+		} else if store.Root() != c.path || store.Depth() != c.depth || store.mode != c.mode {
+			t.Errorf("Save path %q was parsed incorrectly: %#v\n", c.savePath, store)
+		}
+	}
+
+	for _, savePath := range []string{"x;/tmp", "-1;/tmp", "1;999;/tmp"} {
+		if _, err := NewStore(savePath); err == nil {
+			t.Errorf("Save path %q should not be parsed\n", savePath)
+		}
+	}
+}
+
+func TestPath(t *testing.T) {
+	store, _ := NewStore("2;/sessions")
+	if name, err := store.Path("abcdef"); err != nil {
+		t.Errorf("Can not build path: %v\n", err)
+	} else if name != filepath.Join("/sessions", "a", "b", "sess_abcdef") {
+		t.Errorf("Path was built incorrectly: %v\n", name)
+	}
+
+	for _, id := range []string{"", "ab", "../../etc/passwd", "abc def"} {
+		if _, err := store.Path(id); err == nil {
+			t.Errorf("Session id %q should be rejected\n", id)
+		}
+	}
+}
+
+func TestReadWrite(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "a", "b"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	store, _ := NewStore("2;" + dir)
+
+	if _, err := store.Read("abcdef"); !errors.Is(err, php_session_decoder.ErrNotFound) {
+		t.Errorf("Missing session should not be found, have got: %v\n", err)
+	}
+
+	session := php_session_decoder.NewPhpSession().Set("user_id", 42)
+	if err := php_session_decoder.Save(store, "abcdef", session, php_session_decoder.FORMAT_PHP); err != nil {
+		t.Errorf("Can not save session: %v\n", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "a", "b", "sess_abcdef")); string(data) != "user_id|i:42;" {
+		t.Errorf("Session file was written incorrectly: %q\n", data)
+	}
+
+	// shorter data has to replace the previous one completely
+	if err := store.Write("abcdef", "a|b:1;"); err != nil {
+		t.Errorf("Can not write session: %v\n", err)
+	}
+	if result, err := php_session_decoder.Load(store, "abcdef", php_session_decoder.FORMAT_PHP); err != nil {
+		t.Errorf("Can not load session: %v\n", err)
+	} else if v, _ := result.Get("a"); result.Len() != 1 || v != true {
+		t.Errorf("Session was loaded incorrectly: %#v\n", result)
+	}
+
+	if err := store.Destroy("abcdef"); err != nil {
+		t.Errorf("Can not destroy session: %v\n", err)
+	}
+	if _, err := store.Read("abcdef"); !errors.Is(err, php_session_decoder.ErrNotFound) {
+		t.Errorf("Destroyed session should not be found, have got: %v\n", err)
+	}
+	if err := store.Destroy("abcdef"); err != nil {
+		t.Errorf("Destroy of missing session should not fail: %v\n", err)
+	}
+}
+
+func TestOpenLocks(t *testing.T) {
+	store, _ := NewStore(t.TempDir())
+
+	file, err := store.Open("abcdef")
+	if err != nil {
+		t.Fatalf("Can not open session: %v\n", err)
+	}
+
+	written := make(chan error)
+	go func() {
+		written <- store.Write("abcdef", "b|i:2;")
+	}()
+
+	select {
+	case err := <-written:
+		t.Fatalf("Write has not waited for the lock: %v\n", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := file.Write("a|i:1;"); err != nil {
+		t.Errorf("Can not write locked session: %v\n", err)
+	}
+	file.Close()
+
+	if err := <-written; err != nil {
+		t.Errorf("Can not write session after unlock: %v\n", err)
+	}
+	if data, _ := store.Read("abcdef"); data != "b|i:2;" {
+		t.Errorf("Session was written incorrectly: %q\n", data)
+	}
+}
+
+func TestLock(t *testing.T) {
+	store, _ := NewStore(t.TempDir())
+
+	locked, unlock, err := php_session_decoder.Lock(store, "abcdef", time.Second)
+	if err != nil {
+		t.Fatalf("Can not lock session: %v\n", err)
+	}
+	if _, _, err := php_session_decoder.Lock(store, "abcdef", 50*time.Millisecond); !errors.Is(err, php_session_decoder.ErrLockTimeout) {
+		t.Errorf("Locked session should not be locked again: %v\n", err)
+	}
+	if handle, err := store.TryLockHandle("abcdef"); err != nil || handle != nil {
+		t.Errorf("Locked session should not be locked again: %v %v\n", handle, err)
+	}
+
+	// the lock is not shared with other callers of the store
+	read := make(chan string)
+	go func() {
+		data, _ := store.Read("abcdef")
+		read <- data
+	}()
+	select {
+	case data := <-read:
+		t.Fatalf("Read has not waited for the lock: %q\n", data)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := locked.Write("abcdef", "a|i:1;"); err != nil {
+		t.Errorf("Can not write locked session: %v\n", err)
+	}
+	if data, err := locked.Read("abcdef"); err != nil || data != "a|i:1;" {
+		t.Errorf("Can not read locked session: %q %v\n", data, err)
+	}
+	if err := unlock(); err != nil {
+		t.Errorf("Can not unlock session: %v\n", err)
+	}
+	if data := <-read; data != "a|i:1;" {
+		t.Errorf("Session was read incorrectly after unlock: %q\n", data)
+	}
+
+	err = php_session_decoder.Update(store, "abcdef", php_session_decoder.FORMAT_PHP, time.Second, func(session *php_session_decoder.PhpSession) error {
+		session.Set("a", 2)
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Can not update session: %v\n", err)
+	}
+	if data, _ := store.Read("abcdef"); data != "a|i:2;" {
+		t.Errorf("Session was updated incorrectly: %q\n", data)
+	}
+}
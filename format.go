@@ -0,0 +1,106 @@
+package php_session_decoder
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Format is the name of session.serialize_handler which has written the session data.
+type Format string
+
+const (
+	FORMAT_UNKNOWN       Format = ""
+	FORMAT_PHP           Format = "php"
+	FORMAT_PHP_BINARY    Format = "php_binary"
+	FORMAT_PHP_SERIALIZE Format = "php_serialize"
+	FORMAT_IGBINARY      Format = "igbinary"
+)
+
+var (
+	ErrUnknownFormat     = errors.New("php_session: Unable to detect format of session data")
+	ErrUnsupportedFormat = errors.New("php_session: Format of session data is not supported")
+)
+
+// igbinary serializer starts its output with big-endian version number 1 or 2
+var igbinaryHeaders = []string{"\x00\x00\x00\x01", "\x00\x00\x00\x02"}
+
+// DetectFormat returns the format of session data or FORMAT_UNKNOWN if data does not look like a session.
+// Empty data is valid in any format, so it is reported as FORMAT_UNKNOWN too.
+func DetectFormat(phpSession string) Format {
+	_, format, _ := DecodeAuto(phpSession)
+	return format
+}
+
+// DecodeAuto detects the format of session data and decodes it.
+// The detected format is returned even when the data can't be decoded, e.g. for igbinary.
+func DecodeAuto(phpSession string) (*PhpSession, Format, error) {
+	if phpSession == "" {
+		return NewPhpSession(), FORMAT_UNKNOWN, nil
+	}
+
+	for _, header := range igbinaryHeaders {
+		if strings.HasPrefix(phpSession, header) {
+			return nil, FORMAT_IGBINARY, ErrUnsupportedFormat
+		}
+	}
+
+	for _, format := range candidateFormats(phpSession) {
+		if res, err := decodeStrict(phpSession, format); err == nil {
+			return res, format, nil
+		}
+	}
+	return nil, FORMAT_UNKNOWN, ErrUnknownFormat
+}
+
+// DecodeFormat decodes session data written in given format.
+func DecodeFormat(phpSession string, format Format) (*PhpSession, error) {
+	switch format {
+	case FORMAT_PHP:
+		return Decode(phpSession)
+	case FORMAT_PHP_BINARY:
+		return DecodeBinary(phpSession)
+	case FORMAT_PHP_SERIALIZE:
+		return DecodeSerialize(phpSession)
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+}
+
+// EncodeFormat encodes session data in given format, usually the one reported by DecodeAuto.
+func EncodeFormat(data *PhpSession, format Format) (string, error) {
+	switch format {
+	case FORMAT_PHP:
+		return Encode(data)
+	case FORMAT_PHP_BINARY:
+		return EncodeBinary(data)
+	case FORMAT_PHP_SERIALIZE:
+		return EncodeSerialize(data)
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+}
+
+// candidateFormats orders formats by how likely they are for the first bytes of the data.
+func candidateFormats(phpSession string) []Format {
+	// php_binary starts with length of the name, it is a printable char only for long names
+	if first := phpSession[0]; first < 0x20 || first >= 0x7f {
+		return []Format{FORMAT_PHP_BINARY, FORMAT_PHP}
+	}
+	if strings.HasPrefix(phpSession, "a:") {
+		return []Format{FORMAT_PHP_SERIALIZE, FORMAT_PHP, FORMAT_PHP_BINARY}
+	}
+	return []Format{FORMAT_PHP, FORMAT_PHP_BINARY}
+}
+
+func decodeStrict(phpSession string, format Format) (*PhpSession, error) {
+	switch format {
+	case FORMAT_PHP:
+		decoder := NewPhpDecoder(phpSession)
+		decoder.strict = true
+		return decoder.Decode()
+	case FORMAT_PHP_SERIALIZE:
+		decoder := NewPhpSerializeDecoder(phpSession)
+		decoder.strict = true
+		return decoder.Decode()
+	}
+	return DecodeFormat(phpSession, format)
+}
@@ -0,0 +1,68 @@
+package php_session_decoder
+
+import (
+	"errors"
+	"io/ioutil"
+	"testing"
+)
+
+func TestDetectFormat(t *testing.T) {
+	cases := map[string]Format{
+		"":                                   FORMAT_UNKNOWN,
+		"login_ok|b:1;inteiro|i:34;":         FORMAT_PHP,
+		"arr|a:1:{s:4:\"test\";b:1;}":        FORMAT_PHP,
+		"\x08login_okb:1;\x07inteiroi:34;":   FORMAT_PHP_BINARY,
+		"a:1:{s:8:\"login_ok\";b:1;}":        FORMAT_PHP_SERIALIZE,
+		"a:1:{s:3:\"a|b\";b:1;}":             FORMAT_PHP_SERIALIZE,
+		"a:0:{}":                             FORMAT_PHP_SERIALIZE,
+		"\x00\x00\x00\x02\x14\x01\x11\x01a":  FORMAT_IGBINARY,
+		"a:1:{s:8:\"login_ok\";b:1;}garbage": FORMAT_UNKNOWN,
+		"no separator":                       FORMAT_UNKNOWN,
+	}
+
+	for data, expected := range cases {
+		if format := DetectFormat(data); format != expected {
+			t.Errorf("Format of %q was detected incorrectly, expected: %q, have got: %q\n", data, expected, format)
+		}
+	}
+}
+
+func TestDecodeAutoRealData(t *testing.T) {
+	testData, _ := ioutil.ReadFile("./data/test.session")
+	if result, format, err := DecodeAuto(string(testData)); err != nil {
+		t.Errorf("Can not decode session %#v \n", err)
+	} else if format != FORMAT_PHP {
+		t.Errorf("Format was detected incorrectly: %q\n", format)
+	} else if _, ok := result.Get("product_last_viewed"); !ok {
+		t.Errorf("Can not find product_last_viewed key\n")
+	}
+}
+
+func TestDecodeAutoRoundTrip(t *testing.T) {
+	data := NewPhpSession().Set("inteiro", 34).Set("name", "some text")
+
+	for _, format := range []Format{FORMAT_PHP, FORMAT_PHP_BINARY, FORMAT_PHP_SERIALIZE} {
+		if encoded, err := EncodeFormat(data, format); err != nil {
+			t.Errorf("Can not encode session in %q format: %#v \n", format, err)
+		} else if result, detected, err := DecodeAuto(encoded); err != nil {
+			t.Errorf("Can not decode session in %q format: %#v \n", format, err)
+		} else if detected != format {
+			t.Errorf("Format was detected incorrectly, expected: %q, have got: %q\n", format, detected)
+		} else if result.Len() != 2 {
+			t.Errorf("Session was decoded incorrectly: %#v\n", result)
+		} else if v, _ := result.Get("inteiro"); v != 34 {
+			t.Errorf("Session was decoded incorrectly: %#v\n", result)
+		} else if v, _ := result.Get("name"); v != "some text" {
+			t.Errorf("Session was decoded incorrectly: %#v\n", result)
+		}
+	}
+}
+
+func TestDecodeAutoUnsupported(t *testing.T) {
+	if _, format, err := DecodeAuto("\x00\x00\x00\x02\x14\x00"); format != FORMAT_IGBINARY || !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Igbinary session should be detected but not decoded, have got: %q, %v\n", format, err)
+	}
+	if _, err := EncodeFormat(NewPhpSession(), FORMAT_IGBINARY); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Igbinary session should not be encoded, have got: %v\n", err)
+	}
+}
@@ -0,0 +1,2 @@
+// Package httpsession provides net/http middleware which shares PHP sessions with Go handlers.
+package httpsession
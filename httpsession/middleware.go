@@ -0,0 +1,227 @@
+package httpsession
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/solidwall/php_session_decoder"
+)
+
+// Options mirror session.* settings of php.ini, zero values are replaced with PHP defaults.
+type Options struct {
+	Store php_session_decoder.Store
+	// Format is session.serialize_handler, FORMAT_PHP by default.
+	Format php_session_decoder.Format
+
+	// CookieName is session.name, PHPSESSID by default.
+	CookieName string
+	// CookieLifetime is session.cookie_lifetime, zero keeps the cookie until the browser is closed.
+	CookieLifetime time.Duration
+	// CookiePath is session.cookie_path, "/" by default.
+	CookiePath     string
+	CookieDomain   string
+	CookieSecure   bool
+	CookieHttpOnly bool
+	CookieSameSite http.SameSite
+
+	// GenerateId returns id of new sessions, php_session_decoder.GenerateId by default.
+	GenerateId func() (string, error)
+	// ErrorHandler responds when the session can't be loaded, with 500 Internal Server Error by default.
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+	// ErrorLog logs errors of writing sessions after the response, the log package's standard logger by default.
+	ErrorLog *log.Logger
+}
+
+type contextKey struct{}
+
+// FromContext returns the session loaded by the middleware.
+func FromContext(ctx context.Context) (*php_session_decoder.PhpSession, bool) {
+	state, ok := ctx.Value(contextKey{}).(*requestSession)
+	if !ok {
+		return nil, false
+	}
+	return state.session, true
+}
+
+// Middleware loads the session by the cookie before calling next handler and
+// saves it after, only changed sessions are written. New sessions
+// get the cookie only when they are modified before the response is started.
+func Middleware(options Options) func(http.Handler) http.Handler {
+	if options.Format == php_session_decoder.FORMAT_UNKNOWN {
+		options.Format = php_session_decoder.FORMAT_PHP
+	}
+	if options.CookieName == "" {
+		options.CookieName = "PHPSESSID"
+	}
+	if options.CookiePath == "" {
+		options.CookiePath = "/"
+	}
+	if options.GenerateId == nil {
+		options.GenerateId = php_session_decoder.GenerateId
+	}
+	if options.ErrorHandler == nil {
+		options.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			state, err := load(r, &options)
+			if err != nil {
+				options.ErrorHandler(w, r, err)
+				return
+			}
+
+			sw := &sessionWriter{ResponseWriter: w, state: state}
+			next.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), contextKey{}, state)))
+			if !sw.wroteHeader {
+				// net/http writes headers after the handler returns, so the cookie still can be set
+				if err = state.startResponse(w); err != nil {
+					options.logf("httpsession: unable to start session: %v", err)
+				}
+			}
+
+			if err = state.save(); err != nil {
+				options.logf("httpsession: unable to write session: %v", err)
+			}
+		})
+	}
+}
+
+// requestSession is the session of a single request.
+type requestSession struct {
+	options *Options
+	id      string
+	session *php_session_decoder.PhpSession
+	// cookieSent means the client knows id of the new session
+	cookieSent bool
+	saved      bool
+}
+
+func load(r *http.Request, options *Options) (*requestSession, error) {
+	state := &requestSession{
+		options: options,
+		session: php_session_decoder.NewPhpSession(),
+	}
+	// invalid and unknown ids are replaced with new ones as PHP does with session.use_strict_mode,
+	// otherwise the client could choose the id of the session
+	cookie, err := r.Cookie(options.CookieName)
+	if err != nil || php_session_decoder.ValidateId(cookie.Value) != nil {
+		return state, nil
+	}
+
+	data, err := options.Store.Read(cookie.Value)
+	if errors.Is(err, php_session_decoder.ErrNotFound) {
+		return state, nil
+	} else if err != nil {
+		return nil, err
+	}
+	state.id = cookie.Value
+	state.cookieSent = true
+	if state.session, err = php_session_decoder.DecodeFormat(data, options.Format); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// startResponse sets the cookie of the new session before headers are written.
+func (rs *requestSession) startResponse(w http.ResponseWriter) error {
+	if rs.cookieSent || !rs.session.Changed() {
+		return nil
+	}
+
+	id, err := rs.options.GenerateId()
+	if err != nil {
+		return err
+	}
+	rs.id = id
+	rs.cookieSent = true
+	http.SetCookie(w, rs.options.cookie(id))
+	return nil
+}
+
+// save writes changed session and touches unchanged one like PHP does with session.lazy_write.
+func (rs *requestSession) save() error {
+	if rs.saved || !rs.cookieSent {
+		return nil
+	}
+	rs.saved = true
+	return php_session_decoder.Save(rs.options.Store, rs.id, rs.session, rs.options.Format)
+}
+
+func (o *Options) cookie(id string) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     o.CookieName,
+		Value:    id,
+		Path:     o.CookiePath,
+		Domain:   o.CookieDomain,
+		Secure:   o.CookieSecure,
+		HttpOnly: o.CookieHttpOnly,
+		SameSite: o.CookieSameSite,
+	}
+	if o.CookieLifetime > 0 {
+		cookie.Expires = time.Now().Add(o.CookieLifetime)
+		cookie.MaxAge = int(o.CookieLifetime / time.Second)
+	}
+	return cookie
+}
+
+func (o *Options) logf(format string, args ...interface{}) {
+	if o.ErrorLog != nil {
+		o.ErrorLog.Printf(format, args...)
+	} else {
+		log.Printf(format, args...)
+	}
+}
+
+// sessionWriter sets the session cookie when the handler starts the response.
+type sessionWriter struct {
+	http.ResponseWriter
+	state       *requestSession
+	wroteHeader bool
+}
+
+func (sw *sessionWriter) WriteHeader(code int) {
+	if !sw.wroteHeader {
+		sw.wroteHeader = true
+		if err := sw.state.startResponse(sw.ResponseWriter); err != nil {
+			sw.state.options.logf("httpsession: unable to start session: %v", err)
+		}
+	}
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+func (sw *sessionWriter) Write(b []byte) (int, error) {
+	if !sw.wroteHeader {
+		sw.WriteHeader(http.StatusOK)
+	}
+	return sw.ResponseWriter.Write(b)
+}
+
+func (sw *sessionWriter) Flush() {
+	if !sw.wroteHeader {
+		sw.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := sw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (sw *sessionWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := sw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("httpsession: response writer does not support hijacking")
+	}
+	return hijacker.Hijack()
+}
+
+// Unwrap lets http.ResponseController reach the original writer.
+func (sw *sessionWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
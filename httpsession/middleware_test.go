@@ -0,0 +1,217 @@
+package httpsession
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/solidwall/php_session_decoder"
+)
+
+type memoryStore struct {
+	mu       sync.Mutex
+	sessions map[string]string
+	writes   int
+	touches  int
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{sessions: map[string]string{}}
+}
+
+func (s *memoryStore) Read(id string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.sessions[id]
+	if !ok {
+		return "", php_session_decoder.ErrNotFound
+	}
+	return data, nil
+}
+
+func (s *memoryStore) Write(id string, data string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[id] = data
+	s.writes++
+	return nil
+}
+
+func (s *memoryStore) Touch(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.touches++
+	return nil
+}
+
+func (s *memoryStore) Destroy(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, id)
+	return nil
+}
+
+func serve(handler http.Handler, cookie *http.Cookie) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("GET", "/", nil)
+	if cookie != nil {
+		r.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestLoadSession(t *testing.T) {
+	store := newMemoryStore()
+	store.sessions["abc"] = `user_id|i:42;login|s:5:"admin";`
+
+	handler := Middleware(Options{Store: store})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session, ok := FromContext(r.Context())
+		if !ok {
+			t.Errorf("Session is missing in the context\n")
+			return
+		}
+		if v, _ := session.Get("user_id"); v != 42 {
+			t.Errorf("Session was loaded incorrectly: %#v\n", session)
+		}
+		w.Write([]byte("ok"))
+	}))
+
+	w := serve(handler, &http.Cookie{Name: "PHPSESSID", Value: "abc"})
+	if w.Body.String() != "ok" {
+		t.Errorf("Handler was not called: %q\n", w.Body.String())
+	}
+	if store.writes != 0 || store.touches != 1 {
+		t.Errorf("Unmodified session should be touched only: %d writes, %d touches\n", store.writes, store.touches)
+	}
+	if len(w.Result().Cookies()) != 0 {
+		t.Errorf("Cookie of existing session should not be sent\n")
+	}
+}
+
+func TestWriteModifiedSession(t *testing.T) {
+	store := newMemoryStore()
+	store.sessions["abc"] = `user_id|i:42;`
+
+	handler := Middleware(Options{Store: store, CookieName: "sid"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session, _ := FromContext(r.Context())
+		session.Set("visits", 1)
+	}))
+
+	serve(handler, &http.Cookie{Name: "sid", Value: "abc"})
+	if data := store.sessions["abc"]; data != `user_id|i:42;visits|i:1;` {
+		t.Errorf("Session was written incorrectly: %q\n", data)
+	}
+}
+
+func TestNewSession(t *testing.T) {
+	store := newMemoryStore()
+	options := Options{
+		Store:          store,
+		Format:         php_session_decoder.FORMAT_PHP_SERIALIZE,
+		CookieLifetime: time.Hour,
+		CookieDomain:   "example.com",
+		CookieSecure:   true,
+		CookieHttpOnly: true,
+		CookieSameSite: http.SameSiteLaxMode,
+		GenerateId:     func() (string, error) { return "new", nil },
+	}
+
+	handler := Middleware(options)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("set") != "" {
+			session, _ := FromContext(r.Context())
+			session.Set("a", true)
+		}
+		w.Write([]byte("ok"))
+	}))
+
+	if w := serve(handler, nil); len(w.Result().Cookies()) != 0 || store.writes != 0 {
+		t.Errorf("Empty new session should not be started\n")
+	}
+
+	r := httptest.NewRequest("GET", "/?set=1", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("Cookie of new session was not sent: %v\n", w.Header())
+	}
+	cookie := cookies[0]
+	if cookie.Name != "PHPSESSID" || cookie.Value != "new" || cookie.Path != "/" || cookie.Domain != "example.com" {
+		t.Errorf("Cookie was sent incorrectly: %v\n", cookie)
+	} else if !cookie.Secure || !cookie.HttpOnly || cookie.SameSite != http.SameSiteLaxMode || cookie.MaxAge != 3600 {
+		t.Errorf("Cookie parameters were not applied: %v\n", cookie)
+	}
+	if data := store.sessions["new"]; data != `a:1:{s:1:"a";b:1;}` {
+		t.Errorf("New session was written incorrectly: %q\n", data)
+	}
+}
+
+func TestNewSessionWithoutResponse(t *testing.T) {
+	store := newMemoryStore()
+	handler := Middleware(Options{Store: store, GenerateId: func() (string, error) { return "new", nil }})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session, _ := FromContext(r.Context())
+		session.Set("a", 1)
+	}))
+
+	w := serve(handler, nil)
+	if cookies := w.Result().Cookies(); len(cookies) != 1 || cookies[0].Value != "new" {
+		t.Errorf("Cookie of new session was not sent: %v\n", w.Header())
+	}
+	if data := store.sessions["new"]; data != "a|i:1;" {
+		t.Errorf("New session was written incorrectly: %q\n", data)
+	}
+}
+
+func TestLoadError(t *testing.T) {
+	store := newMemoryStore()
+	store.sessions["abc"] = `a|s:10:"x";`
+
+	handler := Middleware(Options{Store: store})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Handler should not be called\n")
+	}))
+
+	w := serve(handler, &http.Cookie{Name: "PHPSESSID", Value: "abc"})
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Error was not handled: %v\n", w.Code)
+	}
+}
+
+func TestInvalidCookie(t *testing.T) {
+	store := newMemoryStore()
+	store.sessions["../abc"] = "a|i:1;"
+
+	handler := Middleware(Options{Store: store, GenerateId: func() (string, error) { return "new", nil }})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session, _ := FromContext(r.Context())
+		if session.Len() != 0 {
+			t.Errorf("Session with invalid id should not be loaded: %#v\n", session)
+		}
+		session.Set("b", 2)
+	}))
+
+	w := serve(handler, &http.Cookie{Name: "PHPSESSID", Value: "../abc"})
+	if cookies := w.Result().Cookies(); len(cookies) != 1 || cookies[0].Value != "new" {
+		t.Errorf("Invalid id was not replaced: %v\n", w.Header())
+	}
+}
+
+func TestUnknownCookie(t *testing.T) {
+	store := newMemoryStore()
+	handler := Middleware(Options{Store: store, GenerateId: func() (string, error) { return "new", nil }})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session, _ := FromContext(r.Context())
+		session.Set("user_id", 42)
+	}))
+
+	w := serve(handler, &http.Cookie{Name: "PHPSESSID", Value: "chosenbyattacker"})
+	if cookies := w.Result().Cookies(); len(cookies) != 1 || cookies[0].Value != "new" {
+		t.Errorf("Unknown id was not replaced: %v\n", w.Header())
+	}
+	if _, ok := store.sessions["chosenbyattacker"]; ok {
+		t.Errorf("Session should not be written with unknown id\n")
+	}
+	if data := store.sessions["new"]; data != "user_id|i:42;" {
+		t.Errorf("New session was written incorrectly: %q\n", data)
+	}
+}
@@ -0,0 +1,102 @@
+package php_session_decoder
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	SID_LENGTH_DEFAULT = 32
+	SID_LENGTH_MIN     = 22
+	SID_LENGTH_MAX     = 256
+
+	SID_BITS_PER_CHARACTER_DEFAULT = 4
+
+	// SID_CHARS is the alphabet of session ids, session.sid_bits_per_character
+	// selects its first 16, 32 or all 64 chars.
+	SID_CHARS = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ,-"
+)
+
+var ErrInvalidId = errors.New("php_session: Invalid session id")
+
+// IdGenerator creates session ids the same way PHP does for given
+// session.sid_length and session.sid_bits_per_character.
+type IdGenerator struct {
+	length int
+	bits   int
+}
+
+func NewIdGenerator(sidLength int, sidBitsPerCharacter int) (*IdGenerator, error) {
+	if sidLength < SID_LENGTH_MIN || sidLength > SID_LENGTH_MAX {
+		return nil, fmt.Errorf("php_session: session.sid_length must be between %d and %d, got %d", SID_LENGTH_MIN, SID_LENGTH_MAX, sidLength)
+	}
+	if sidBitsPerCharacter < 4 || sidBitsPerCharacter > 6 {
+		return nil, fmt.Errorf("php_session: session.sid_bits_per_character must be 4, 5 or 6, got %d", sidBitsPerCharacter)
+	}
+	return &IdGenerator{length: sidLength, bits: sidBitsPerCharacter}, nil
+}
+
+// GenerateId creates session id with PHP defaults: 32 hex chars.
+func GenerateId() (string, error) {
+	generator := IdGenerator{length: SID_LENGTH_DEFAULT, bits: SID_BITS_PER_CHARACTER_DEFAULT}
+	return generator.Generate()
+}
+
+func (g *IdGenerator) Generate() (string, error) {
+	random := make([]byte, g.length)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return g.readable(random), nil
+}
+
+// readable converts random bytes to chars taking the lowest bits first, like bin_to_readable of PHP.
+func (g *IdGenerator) readable(random []byte) string {
+	var (
+		id   = make([]byte, g.length)
+		mask = uint(1)<<uint(g.bits) - 1
+		w    uint
+		have int
+	)
+	for i := range id {
+		if have < g.bits {
+			w |= uint(random[0]) << uint(have)
+			random = random[1:]
+			have += 8
+		}
+		id[i] = SID_CHARS[w&mask]
+		w >>= uint(g.bits)
+		have -= g.bits
+	}
+	return string(id)
+}
+
+// Validate checks that the id could be created by this generator.
+func (g *IdGenerator) Validate(id string) error {
+	if len(id) != g.length {
+		return fmt.Errorf("%w %q: its length is not %d", ErrInvalidId, id, g.length)
+	}
+	alphabet := SID_CHARS[:1<<uint(g.bits)]
+	for i := 0; i < len(id); i++ {
+		if strings.IndexByte(alphabet, id[i]) < 0 {
+			return fmt.Errorf("%w %q: it contains %q which is not used with %d bits per character", ErrInvalidId, id, id[i], g.bits)
+		}
+	}
+	return nil
+}
+
+// ValidateId checks the id the way PHP does before using it, PHP replaces
+// rejected ids with new ones.
+func ValidateId(id string) error {
+	if id == "" || len(id) > SID_LENGTH_MAX {
+		return fmt.Errorf("%w %q: its length must be between 1 and %d", ErrInvalidId, id, SID_LENGTH_MAX)
+	}
+	for i := 0; i < len(id); i++ {
+		if strings.IndexByte(SID_CHARS, id[i]) < 0 {
+			return fmt.Errorf("%w %q: it contains %q", ErrInvalidId, id, id[i])
+		}
+	}
+	return nil
+}
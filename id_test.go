@@ -0,0 +1,69 @@
+package php_session_decoder
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestIdGenerator(t *testing.T) {
+	for _, bits := range []int{4, 5, 6} {
+		generator, err := NewIdGenerator(26, bits)
+		if err != nil {
+			t.Errorf("Can not create generator: %v\n", err)
+			continue
+		}
+		id, err := generator.Generate()
+		if err != nil {
+			t.Errorf("Can not generate id: %v\n", err)
+		} else if len(id) != 26 || strings.Trim(id, SID_CHARS[:1<<uint(bits)]) != "" {
+			t.Errorf("Id was generated incorrectly for %d bits: %q\n", bits, id)
+		}
+		if err = generator.Validate(id); err != nil {
+			t.Errorf("Generated id is not valid: %v\n", err)
+		}
+		if err = ValidateId(id); err != nil {
+			t.Errorf("Generated id is not valid for PHP: %v\n", err)
+		}
+	}
+
+	if _, err := NewIdGenerator(21, 4); err == nil {
+		t.Errorf("Too short sid_length should be rejected\n")
+	} else if _, err := NewIdGenerator(32, 7); err == nil {
+		t.Errorf("Unsupported sid_bits_per_character should be rejected\n")
+	}
+
+	if id, err := GenerateId(); err != nil || len(id) != 32 || strings.Trim(id, "0123456789abcdef") != "" {
+		t.Errorf("Id was generated incorrectly with defaults: %q %v\n", id, err)
+	}
+}
+
+func TestIdGeneratorBitOrder(t *testing.T) {
+	random := []byte{0x21, 0xff, 0x00, 0x80, 0x01}
+	for bits, expected := range map[int]string{4: "12ff", 5: "1pv10", 6: "xYf0"} {
+		generator := &IdGenerator{length: len(expected), bits: bits}
+		if id := generator.readable(random); id != expected {
+			t.Errorf("Bytes were converted incorrectly for %d bits: %q instead of %q\n", bits, id, expected)
+		}
+	}
+}
+
+func TestValidateId(t *testing.T) {
+	for _, id := range []string{"abc", "0123456789abcdefghijklmnopqrstuv", "a,b-C"} {
+		if err := ValidateId(id); err != nil {
+			t.Errorf("Id %q should be valid: %v\n", id, err)
+		}
+	}
+	for _, id := range []string{"", "abc.def", "../etc", "ab cd", strings.Repeat("a", 257)} {
+		if err := ValidateId(id); !errors.Is(err, ErrInvalidId) {
+			t.Errorf("Id %q should be invalid: %v\n", id, err)
+		}
+	}
+
+	generator, _ := NewIdGenerator(22, 5)
+	if err := generator.Validate(strings.Repeat("w", 22)); !errors.Is(err, ErrInvalidId) {
+		t.Errorf("Char outside of 5 bits alphabet should be rejected: %v\n", err)
+	} else if err := generator.Validate(strings.Repeat("a", 23)); !errors.Is(err, ErrInvalidId) {
+		t.Errorf("Id of wrong length should be rejected: %v\n", err)
+	}
+}
@@ -0,0 +1,106 @@
+package laminas
+
+import (
+	"github.com/solidwall/php_session_decoder/php_serialize"
+)
+
+const (
+	STORAGE_PROPERTY = "storage"
+	// index of the storage in the array returned by ArrayObject::__serialize since PHP 7.4
+	STORAGE_INDEX = 1
+)
+
+// containerStorage returns the storage of ArrayObject-derived container. SPL ArrayObject
+// is stored in C: format before PHP 7.4 and in O: format after, Laminas\Stdlib\ArrayObject
+// is stored in O: format with its properties and Zend\Stdlib\ArrayObject in C: format.
+func containerStorage(value php_serialize.PhpValue) (php_serialize.PhpArray, bool) {
+	switch v := value.(type) {
+	case *php_serialize.PhpSplArray:
+		storage, ok := v.GetArray().(php_serialize.PhpArray)
+		return storage, ok
+	case *php_serialize.PhpObjectSerialized:
+		inner, err := serializedValue(v)
+		if err != nil {
+			return nil, false
+		}
+		if array, ok := inner.(php_serialize.PhpArray); ok {
+			// Zend\Stdlib\ArrayObject::serialize serializes the array of its properties
+			storage, ok := array[STORAGE_PROPERTY].(php_serialize.PhpArray)
+			return storage, ok
+		}
+		return containerStorage(inner)
+	case *php_serialize.PhpObject:
+		members := v.GetMembers()
+		if storage, ok := members[STORAGE_PROPERTY].(php_serialize.PhpArray); ok {
+			return storage, true
+		}
+		if storage, ok := v.GetProtected(STORAGE_PROPERTY); ok {
+			storage, ok := storage.(php_serialize.PhpArray)
+			return storage, ok
+		}
+		storage, ok := members[STORAGE_INDEX].(php_serialize.PhpArray)
+		return storage, ok
+	}
+	return nil, false
+}
+
+// withStorage returns a copy of the container with the storage replaced, the class and
+// the other properties are kept so the container is encoded in the same format.
+func withStorage(value php_serialize.PhpValue, storage php_serialize.PhpArray) (php_serialize.PhpValue, error) {
+	switch v := value.(type) {
+	case *php_serialize.PhpSplArray:
+		array := php_serialize.NewPhpSplArray(storage, v.GetProperties())
+		array.SetFlags(v.GetFlags())
+		return array, nil
+	case *php_serialize.PhpObjectSerialized:
+		inner, err := serializedValue(v)
+		if err != nil {
+			return nil, err
+		}
+		if array, ok := inner.(php_serialize.PhpArray); ok {
+			array = copyArray(array)
+			array[STORAGE_PROPERTY] = storage
+			inner = array
+		} else if inner, err = withStorage(inner, storage); err != nil {
+			return nil, err
+		}
+
+		data, err := php_serialize.Serialize(inner)
+		if err != nil {
+			return nil, err
+		}
+		return php_serialize.NewPhpObjectSerialized(v.GetClassName()).SetData(data).SetValue(inner), nil
+	case *php_serialize.PhpObject:
+		object := php_serialize.NewPhpObject(v.GetClassName()).SetMembers(copyArray(v.GetMembers()))
+		if _, ok := v.GetMembers()[STORAGE_PROPERTY]; ok {
+			object.SetPublic(STORAGE_PROPERTY, storage)
+		} else if _, ok := v.GetProtected(STORAGE_PROPERTY); ok {
+			object.SetProtected(STORAGE_PROPERTY, storage)
+		} else {
+			object.GetMembers()[STORAGE_INDEX] = storage
+		}
+		return object, nil
+	}
+	return value, nil
+}
+
+// serializedValue returns the unserialized value of C: object, session decoders
+// keep only raw data of such objects unless the decode func is set.
+func serializedValue(object *php_serialize.PhpObjectSerialized) (php_serialize.PhpValue, error) {
+	if value := object.GetValue(); value != nil {
+		return value, nil
+	}
+	return php_serialize.UnSerialize(object.GetData())
+}
+
+// copyArray copies nested arrays too, so metadata can be modified without touching the session.
+func copyArray(array php_serialize.PhpArray) php_serialize.PhpArray {
+	result := make(php_serialize.PhpArray, len(array))
+	for k, v := range array {
+		if nested, ok := v.(php_serialize.PhpArray); ok {
+			v = copyArray(nested)
+		}
+		result[k] = v
+	}
+	return result
+}
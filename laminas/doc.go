@@ -0,0 +1,2 @@
+// Package laminas provides access to session containers of Laminas and Zend Framework 2/3 applications.
+package laminas
@@ -0,0 +1,243 @@
+package laminas
+
+import (
+	"math"
+	"time"
+
+	"github.com/solidwall/php_session_decoder"
+	"github.com/solidwall/php_session_decoder/php_serialize"
+)
+
+const (
+	KEY_METADATA    = "__Laminas"
+	KEY_METADATA_ZF = "__ZF"
+
+	META_REQUEST_ACCESS_TIME = "_REQUEST_ACCESS_TIME"
+	META_VALID               = "_VALID"
+	META_EXPIRE              = "EXPIRE"
+	META_EXPIRE_HOPS         = "EXPIRE_HOPS"
+	META_EXPIRE_KEYS         = "EXPIRE_KEYS"
+	META_EXPIRE_HOPS_KEYS    = "EXPIRE_HOPS_KEYS"
+
+	HOPS_COUNT = "hops"
+	HOPS_TIME  = "ts"
+)
+
+// Session provides access to containers Laminas keeps in the session. Every container
+// is a session variable with ArrayObject, its expiration is kept in the metadata variable.
+type Session struct {
+	session     *php_session_decoder.PhpSession
+	metadataKey string
+}
+
+// NewSession uses __Laminas metadata, or __ZF metadata of Zend Framework applications if only it is present.
+func NewSession(session *php_session_decoder.PhpSession) *Session {
+	s := &Session{session: session, metadataKey: KEY_METADATA}
+	if _, ok := session.Get(KEY_METADATA); !ok {
+		if _, ok := session.Get(KEY_METADATA_ZF); ok {
+			s.metadataKey = KEY_METADATA_ZF
+		}
+	}
+	return s
+}
+
+// PhpSession returns underlying session variables.
+func (s *Session) PhpSession() *php_session_decoder.PhpSession {
+	return s.session
+}
+
+// MetadataKey returns the name of the metadata variable.
+func (s *Session) MetadataKey() string {
+	return s.metadataKey
+}
+
+// ContainerNames returns names of containers in the order of session variables.
+func (s *Session) ContainerNames() []string {
+	var names []string
+	for _, name := range s.session.Names() {
+		if name == KEY_METADATA || name == KEY_METADATA_ZF {
+			continue
+		}
+		if _, ok := s.containerStorage(name); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Container returns a copy of the container storage as it is stored, ignoring the expiration.
+func (s *Session) Container(name string) (php_serialize.PhpArray, bool) {
+	storage, ok := s.containerStorage(name)
+	if !ok {
+		return nil, false
+	}
+	return copyArray(storage), true
+}
+
+// ContainerAt returns the container storage the way Laminas sees it in the request
+// started at now: expired containers are empty and expired keys are removed.
+// The session is not modified, see Expire.
+func (s *Session) ContainerAt(name string, now time.Time) (php_serialize.PhpArray, bool) {
+	storage, ok := s.Container(name)
+	if !ok {
+		return nil, false
+	}
+	storage, _ = expire(storage, copyArray(s.containerMetadata(name)), now)
+	return storage, true
+}
+
+// Containers returns storages of all containers at now.
+func (s *Session) Containers(now time.Time) map[string]php_serialize.PhpArray {
+	containers := map[string]php_serialize.PhpArray{}
+	for _, name := range s.ContainerNames() {
+		containers[name], _ = s.ContainerAt(name, now)
+	}
+	return containers
+}
+
+// Expire applies the expiration to all containers like Laminas does when they are accessed
+// in the request started at now: expired containers are emptied, expired keys are removed
+// and hops are counted. Sessions without metadata are not modified.
+func (s *Session) Expire(now time.Time) error {
+	metadata := s.metadata()
+	if metadata == nil {
+		return nil
+	}
+
+	metadata[META_REQUEST_ACCESS_TIME] = requestTime(now)
+	for _, name := range s.ContainerNames() {
+		meta, ok := metadata[name].(php_serialize.PhpArray)
+		if !ok {
+			continue
+		}
+
+		storage, _ := s.Container(name)
+		if storage, ok = expire(storage, meta, now); !ok {
+			continue
+		}
+		value, _ := s.session.Get(name)
+		container, err := withStorage(value, storage)
+		if err != nil {
+			return err
+		}
+		s.session.Set(name, container)
+	}
+	s.session.Set(s.metadataKey, metadata)
+	return nil
+}
+
+// Expiration returns the time set by Container::setExpirationSeconds.
+func (s *Session) Expiration(name string) (time.Time, bool) {
+	expiry, ok := s.containerMetadata(name)[META_EXPIRE]
+	if !ok || expiry == nil {
+		return time.Time{}, false
+	}
+	return time.Unix(php_serialize.PhpValueInt64(expiry), 0), true
+}
+
+// ExpirationHops returns the number of requests left, set by Container::setExpirationHops.
+func (s *Session) ExpirationHops(name string) (int, bool) {
+	hops, ok := s.containerMetadata(name)[META_EXPIRE_HOPS].(php_serialize.PhpArray)
+	if !ok {
+		return 0, false
+	}
+	return php_serialize.PhpValueInt(hops[HOPS_COUNT]), true
+}
+
+// RequestAccessTime returns the start time of the last request which used the session.
+func (s *Session) RequestAccessTime() (time.Time, bool) {
+	ts, ok := s.metadata()[META_REQUEST_ACCESS_TIME]
+	if !ok || ts == nil {
+		return time.Time{}, false
+	}
+	sec, frac := math.Modf(php_serialize.PhpValueFloat64(ts))
+	return time.Unix(int64(sec), int64(frac*1e9)), true
+}
+
+func (s *Session) containerStorage(name string) (php_serialize.PhpArray, bool) {
+	value, ok := s.session.Get(name)
+	if !ok {
+		return nil, false
+	}
+	return containerStorage(value)
+}
+
+// metadata returns a copy of the metadata variable, nil if there is no metadata.
+func (s *Session) metadata() php_serialize.PhpArray {
+	value, _ := s.session.Get(s.metadataKey)
+	if metadata, ok := value.(php_serialize.PhpArray); ok {
+		return copyArray(metadata)
+	}
+	return nil
+}
+
+func (s *Session) containerMetadata(name string) php_serialize.PhpArray {
+	meta, _ := s.metadata()[name].(php_serialize.PhpArray)
+	return meta
+}
+
+// expire follows AbstractContainer::expireKeys of Laminas for the whole container: the expiration
+// time is checked first, then the hops. It modifies meta and reports whether the storage was changed.
+func expire(storage, meta php_serialize.PhpArray, now time.Time) (php_serialize.PhpArray, bool) {
+	if meta == nil {
+		return storage, false
+	}
+
+	if expiry, ok := meta[META_EXPIRE]; ok && expiry != nil && now.Unix() > php_serialize.PhpValueInt64(expiry) {
+		delete(meta, META_EXPIRE)
+		return php_serialize.PhpArray{}, true
+	}
+	if keys, ok := meta[META_EXPIRE_KEYS].(php_serialize.PhpArray); ok {
+		changed := false
+		for key, expiry := range keys {
+			if now.Unix() > php_serialize.PhpValueInt64(expiry) {
+				delete(keys, key)
+				if _, ok := storage[key]; ok {
+					delete(storage, key)
+					changed = true
+				}
+			}
+		}
+		// like Laminas, hops are not counted when keys have expiration time
+		return storage, changed
+	}
+
+	ts := requestTime(now)
+	if hops, ok := meta[META_EXPIRE_HOPS].(php_serialize.PhpArray); ok && ts > php_serialize.PhpValueFloat64(hops[HOPS_TIME]) {
+		count := php_serialize.PhpValueInt(hops[HOPS_COUNT]) - 1
+		if count == -1 {
+			delete(meta, META_EXPIRE_HOPS)
+			return php_serialize.PhpArray{}, true
+		}
+		hops[HOPS_COUNT] = count
+		hops[HOPS_TIME] = ts
+		return storage, false
+	}
+	if keys, ok := meta[META_EXPIRE_HOPS_KEYS].(php_serialize.PhpArray); ok {
+		changed := false
+		for key, value := range keys {
+			hops, ok := value.(php_serialize.PhpArray)
+			if !ok || ts <= php_serialize.PhpValueFloat64(hops[HOPS_TIME]) {
+				continue
+			}
+			count := php_serialize.PhpValueInt(hops[HOPS_COUNT]) - 1
+			if count == -1 {
+				delete(keys, key)
+				if _, ok := storage[key]; ok {
+					delete(storage, key)
+					changed = true
+				}
+				continue
+			}
+			hops[HOPS_COUNT] = count
+			hops[HOPS_TIME] = ts
+		}
+		return storage, changed
+	}
+	return storage, false
+}
+
+// requestTime is microtime(true) Laminas stores as the request access time.
+func requestTime(now time.Time) float64 {
+	return float64(now.UnixNano()) / float64(time.Second)
+}
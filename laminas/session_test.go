@@ -0,0 +1,162 @@
+package laminas
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/solidwall/php_session_decoder"
+	"github.com/solidwall/php_session_decoder/php_serialize"
+)
+
+const testSession = `__Laminas|a:3:{s:20:"_REQUEST_ACCESS_TIME";d:1700000000.5;` +
+	`s:7:"Default";a:1:{s:6:"EXPIRE";i:1700000100;}` +
+	`s:4:"csrf";a:1:{s:11:"EXPIRE_HOPS";a:2:{s:4:"hops";i:1;s:2:"ts";d:1700000000.5;}}}` +
+	`Default|O:26:"Laminas\Stdlib\ArrayObject":4:{s:7:"storage";a:1:{s:3:"foo";s:3:"bar";}s:4:"flag";i:2;` +
+	`s:13:"iteratorClass";s:13:"ArrayIterator";s:19:"protectedProperties";a:4:{i:0;s:7:"storage";i:1;s:4:"flag";` +
+	`i:2;s:13:"iteratorClass";i:3;s:19:"protectedProperties";}}` +
+	`csrf|O:11:"ArrayObject":4:{i:0;i:0;i:1;a:1:{s:4:"hash";s:3:"abc";}i:2;a:0:{}i:3;N;}` +
+	`user_id|i:5;`
+
+func decodeTestSession(t *testing.T, data string) *Session {
+	session, err := php_session_decoder.Decode(data)
+	if err != nil {
+		t.Fatalf("Can not decode session: %v\n", err)
+	}
+	return NewSession(session)
+}
+
+// reload encodes and decodes the session, containers have to keep their format
+func reload(t *testing.T, session *Session) *Session {
+	data, err := php_session_decoder.Encode(session.PhpSession())
+	if err != nil {
+		t.Fatalf("Can not encode session: %v\n", err)
+	}
+	return decodeTestSession(t, data)
+}
+
+func TestContainers(t *testing.T) {
+	session := decodeTestSession(t, testSession)
+
+	if key := session.MetadataKey(); key != KEY_METADATA {
+		t.Errorf("Metadata key is incorrect: %v\n", key)
+	}
+	if names := session.ContainerNames(); !reflect.DeepEqual(names, []string{"Default", "csrf"}) {
+		t.Errorf("Container names are incorrect: %v\n", names)
+	}
+	if storage, ok := session.Container("Default"); !ok || !reflect.DeepEqual(storage, php_serialize.PhpArray{"foo": "bar"}) {
+		t.Errorf("Laminas ArrayObject was read incorrectly: %#v\n", storage)
+	}
+	if storage, ok := session.Container("csrf"); !ok || !reflect.DeepEqual(storage, php_serialize.PhpArray{"hash": "abc"}) {
+		t.Errorf("ArrayObject was read incorrectly: %#v\n", storage)
+	}
+	if _, ok := session.Container("user_id"); ok {
+		t.Errorf("Scalar variable should not be a container\n")
+	}
+
+	if expiration, ok := session.Expiration("Default"); !ok || expiration.Unix() != 1700000100 {
+		t.Errorf("Expiration is incorrect: %v\n", expiration)
+	}
+	if _, ok := session.Expiration("csrf"); ok {
+		t.Errorf("Container without expiration time should not have it\n")
+	}
+	if hops, ok := session.ExpirationHops("csrf"); !ok || hops != 1 {
+		t.Errorf("Expiration hops are incorrect: %v\n", hops)
+	}
+	if ts, ok := session.RequestAccessTime(); !ok || ts.UnixMilli() != 1700000000500 {
+		t.Errorf("Request access time is incorrect: %v\n", ts)
+	}
+}
+
+func TestContainerAt(t *testing.T) {
+	session := decodeTestSession(t, testSession)
+
+	containers := session.Containers(time.Unix(1700000050, 0))
+	if len(containers["Default"]) != 1 || len(containers["csrf"]) != 1 {
+		t.Errorf("Containers should not expire yet: %v\n", containers)
+	}
+	if storage, _ := session.ContainerAt("Default", time.Unix(1700000101, 0)); len(storage) != 0 {
+		t.Errorf("Container should expire after its expiration time: %v\n", storage)
+	}
+	if session.PhpSession().Changed() {
+		t.Errorf("Session should not be modified: %v\n", session.PhpSession().ChangedNames())
+	}
+}
+
+func TestExpire(t *testing.T) {
+	session := decodeTestSession(t, testSession)
+
+	if err := session.Expire(time.Unix(1700000001, 0)); err != nil {
+		t.Fatalf("Can not expire containers: %v\n", err)
+	}
+	session = reload(t, session)
+	if hops, _ := session.ExpirationHops("csrf"); hops != 0 {
+		t.Errorf("Hop was not counted: %v\n", hops)
+	}
+	if storage, _ := session.Container("csrf"); len(storage) != 1 {
+		t.Errorf("Container should be kept until the last hop: %v\n", storage)
+	}
+	if ts, _ := session.RequestAccessTime(); ts.Unix() != 1700000001 {
+		t.Errorf("Request access time was not updated: %v\n", ts)
+	}
+
+	// the same request does not count hops again
+	if err := session.Expire(time.Unix(1700000001, 0)); err != nil {
+		t.Fatalf("Can not expire containers: %v\n", err)
+	}
+	if hops, _ := session.ExpirationHops("csrf"); hops != 0 {
+		t.Errorf("Hop was counted twice: %v\n", hops)
+	}
+
+	if err := session.Expire(time.Unix(1700000200, 0)); err != nil {
+		t.Fatalf("Can not expire containers: %v\n", err)
+	}
+	session = reload(t, session)
+	if names := session.ContainerNames(); !reflect.DeepEqual(names, []string{"Default", "csrf"}) {
+		t.Errorf("Expired containers should be kept empty: %v\n", names)
+	}
+	for _, name := range []string{"Default", "csrf"} {
+		if storage, _ := session.Container(name); len(storage) != 0 {
+			t.Errorf("Container %s should expire: %v\n", name, storage)
+		}
+	}
+	if _, ok := session.Expiration("Default"); ok {
+		t.Errorf("Expiration time should be removed\n")
+	}
+	if _, ok := session.ExpirationHops("csrf"); ok {
+		t.Errorf("Expiration hops should be removed\n")
+	}
+	if object, _ := session.PhpSession().Get("Default"); object.(*php_serialize.PhpObject).GetClassName() != `Laminas\Stdlib\ArrayObject` {
+		t.Errorf("Container class was not kept: %#v\n", object)
+	}
+}
+
+func TestExpireKeys(t *testing.T) {
+	zend := `a:4:{s:7:"storage";a:2:{s:1:"a";i:1;s:1:"b";i:2;}s:4:"flag";i:2;` +
+		`s:13:"iteratorClass";s:13:"ArrayIterator";s:19:"protectedProperties";a:0:{}}`
+	spl := `x:i:0;a:2:{s:1:"x";i:1;s:1:"y";i:2;};m:a:0:{}`
+	data := `__ZF|a:2:{s:4:"zend";a:1:{s:11:"EXPIRE_KEYS";a:1:{s:1:"a";i:1700000000;}}` +
+		`s:3:"spl";a:1:{s:16:"EXPIRE_HOPS_KEYS";a:1:{s:1:"x";a:2:{s:4:"hops";i:0;s:2:"ts";d:1700000000;}}}}` +
+		fmt.Sprintf(`zend|C:23:"Zend\Stdlib\ArrayObject":%d:{%s}`, len(zend), zend) +
+		fmt.Sprintf(`spl|C:11:"ArrayObject":%d:{%s}`, len(spl), spl)
+	session := decodeTestSession(t, data)
+
+	if key := session.MetadataKey(); key != KEY_METADATA_ZF {
+		t.Errorf("Metadata key is incorrect: %v\n", key)
+	}
+	if storage, _ := session.ContainerAt("zend", time.Unix(1700000000, 0)); len(storage) != 2 {
+		t.Errorf("Key should not expire yet: %v\n", storage)
+	}
+
+	if err := session.Expire(time.Unix(1700000001, 0)); err != nil {
+		t.Fatalf("Can not expire containers: %v\n", err)
+	}
+	session = reload(t, session)
+	if storage, _ := session.Container("zend"); !reflect.DeepEqual(storage, php_serialize.PhpArray{"b": 2}) {
+		t.Errorf("Key with expiration time should expire: %v\n", storage)
+	}
+	if storage, _ := session.Container("spl"); !reflect.DeepEqual(storage, php_serialize.PhpArray{"y": 2}) {
+		t.Errorf("Key with expiration hops should expire: %v\n", storage)
+	}
+}
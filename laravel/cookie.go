@@ -0,0 +1,57 @@
+package laravel
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strings"
+)
+
+const (
+	SESSION_COOKIE_DEFAULT = "laravel_session"
+	REMEMBER_COOKIE_PREFIX = "remember_web_"
+)
+
+var ErrInvalidCookie = errors.New("laravel: Cookie value does not belong to the cookie")
+
+// CookiePrefix returns the prefix EncryptCookies middleware prepends to cookie values
+// before encryption, so a value can't be moved to another cookie.
+func (e *Encrypter) CookiePrefix(name string) string {
+	h := hmac.New(sha1.New, e.key)
+	h.Write([]byte(name + "v2"))
+	return hex.EncodeToString(h.Sum(nil)) + "|"
+}
+
+// EncryptCookie encrypts the cookie value with its prefix, the result is URL encoded
+// like Symfony does, because PHP decodes `+` in cookies to spaces.
+func (e *Encrypter) EncryptCookie(name string, value string) (string, error) {
+	encrypted, err := e.Encrypt(e.CookiePrefix(name) + value)
+	if err != nil {
+		return "", err
+	}
+	return url.QueryEscape(encrypted), nil
+}
+
+// DecryptCookie decrypts the cookie value and checks its prefix, ErrInvalidCookie is
+// returned for values encrypted for other cookies or without the prefix.
+func (e *Encrypter) DecryptCookie(name string, value string) (string, error) {
+	if strings.Contains(value, "%") {
+		unescaped, err := url.PathUnescape(value)
+		if err != nil {
+			return "", ErrInvalidPayload
+		}
+		value = unescaped
+	}
+
+	decrypted, err := e.Decrypt(value)
+	if err != nil {
+		return "", err
+	}
+	prefix := e.CookiePrefix(name)
+	if len(decrypted) < len(prefix) || !hmac.Equal([]byte(decrypted[:len(prefix)]), []byte(prefix)) {
+		return "", ErrInvalidCookie
+	}
+	return decrypted[len(prefix):], nil
+}
@@ -0,0 +1,30 @@
+package laravel
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+)
+
+func TestCookie(t *testing.T) {
+	encrypter, _ := NewEncrypter(testKey, CIPHER_AES_256_CBC)
+
+	if prefix := encrypter.CookiePrefix(SESSION_COOKIE_DEFAULT); prefix != "7198b0a1a7564e003b468667d6241918ce65aafa|" {
+		t.Errorf("Cookie prefix is incorrect: %q\n", prefix)
+	}
+
+	value, err := encrypter.EncryptCookie(SESSION_COOKIE_DEFAULT, "abcdefghijklmnopqrstuvwxyz0123456789ABCD")
+	if err != nil {
+		t.Fatalf("Can not encrypt cookie: %v\n", err)
+	}
+	if unescaped, _ := url.QueryUnescape(value); unescaped == value {
+		t.Errorf("Cookie value should be URL encoded: %q\n", value)
+	}
+
+	if id, err := encrypter.DecryptCookie(SESSION_COOKIE_DEFAULT, value); err != nil || id != "abcdefghijklmnopqrstuvwxyz0123456789ABCD" {
+		t.Errorf("Cookie was decrypted incorrectly: %q %v\n", id, err)
+	}
+	if _, err := encrypter.DecryptCookie(REMEMBER_COOKIE_PREFIX+"59ba36addc2b2f9401580f014c7f58ea4e30989d", value); !errors.Is(err, ErrInvalidCookie) {
+		t.Errorf("Cookie value should not be accepted by other cookie: %v\n", err)
+	}
+}
@@ -0,0 +1,2 @@
+// Package laravel provides compatibility with sessions and cookies of Laravel applications.
+package laravel
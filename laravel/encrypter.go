@@ -0,0 +1,207 @@
+package laravel
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/solidwall/php_session_decoder/php_serialize"
+)
+
+const (
+	CIPHER_AES_128_CBC = "aes-128-cbc"
+	CIPHER_AES_256_CBC = "aes-256-cbc"
+	CIPHER_AES_128_GCM = "aes-128-gcm"
+	CIPHER_AES_256_GCM = "aes-256-gcm"
+
+	// KEY_PREFIX marks base64 encoded APP_KEY
+	KEY_PREFIX = "base64:"
+)
+
+var (
+	ErrInvalidPayload = errors.New("laravel: The payload is invalid")
+	ErrInvalidMac     = errors.New("laravel: The MAC is invalid")
+	ErrDecrypt        = errors.New("laravel: Could not decrypt the data")
+)
+
+// Encrypter encrypts and decrypts values the same way Illuminate\Encryption\Encrypter does.
+type Encrypter struct {
+	key    []byte
+	cipher string
+	block  cipher.Block
+}
+
+// payload is the JSON envelope of encrypted value, fields are in Laravel order.
+type payload struct {
+	Iv    string `json:"iv"`
+	Value string `json:"value"`
+	Mac   string `json:"mac"`
+	Tag   string `json:"tag"`
+}
+
+// NewEncrypter creates encrypter for APP_KEY, which is usually prefixed with `base64:`, and APP_CIPHER.
+func NewEncrypter(appKey string, cipherName string) (*Encrypter, error) {
+	key := []byte(appKey)
+	if strings.HasPrefix(appKey, KEY_PREFIX) {
+		var err error
+		if key, err = base64.StdEncoding.DecodeString(appKey[len(KEY_PREFIX):]); err != nil {
+			return nil, fmt.Errorf("laravel: unable to decode app key: %v", err)
+		}
+	}
+
+	cipherName = strings.ToLower(cipherName)
+	keyLength := 32
+	switch cipherName {
+	case CIPHER_AES_128_CBC, CIPHER_AES_128_GCM:
+		keyLength = 16
+	case CIPHER_AES_256_CBC, CIPHER_AES_256_GCM:
+	default:
+		return nil, fmt.Errorf("laravel: unsupported cipher %q", cipherName)
+	}
+	if len(key) != keyLength {
+		return nil, fmt.Errorf("laravel: %s cipher requires %d bytes key, got %d", cipherName, keyLength, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Encrypter{key: key, cipher: cipherName, block: block}, nil
+}
+
+// Key returns the raw key, it is used for cookie prefixes too.
+func (e *Encrypter) Key() []byte {
+	return e.key
+}
+
+func (e *Encrypter) aead() bool {
+	return strings.HasSuffix(e.cipher, "-gcm")
+}
+
+// Encrypt encrypts value without serialization, like encryptString does.
+func (e *Encrypter) Encrypt(value string) (string, error) {
+	ivLength := aes.BlockSize
+	if e.aead() {
+		ivLength = 12
+	}
+	iv := make([]byte, ivLength)
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+	return e.encrypt(value, iv)
+}
+
+func (e *Encrypter) encrypt(value string, iv []byte) (string, error) {
+	p := payload{Iv: base64.StdEncoding.EncodeToString(iv)}
+	if e.aead() {
+		gcm, err := cipher.NewGCM(e.block)
+		if err != nil {
+			return "", err
+		}
+		sealed := gcm.Seal(nil, iv, []byte(value), nil)
+		tagStart := len(sealed) - gcm.Overhead()
+		p.Value = base64.StdEncoding.EncodeToString(sealed[:tagStart])
+		p.Tag = base64.StdEncoding.EncodeToString(sealed[tagStart:])
+	} else {
+		// PKCS#7 padding, the same openssl uses
+		padding := aes.BlockSize - len(value)%aes.BlockSize
+		data := append([]byte(value), bytes.Repeat([]byte{byte(padding)}, padding)...)
+		cipher.NewCBCEncrypter(e.block, iv).CryptBlocks(data, data)
+		p.Value = base64.StdEncoding.EncodeToString(data)
+		p.Mac = e.mac(p.Iv, p.Value)
+	}
+
+	encoded, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(encoded), nil
+}
+
+// Decrypt verifies and decrypts the payload without unserialization, like decryptString does.
+func (e *Encrypter) Decrypt(encrypted string) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", ErrInvalidPayload
+	}
+	var p payload
+	// the value is empty for empty strings encrypted with GCM, the tag authenticates it
+	if err = json.Unmarshal(decoded, &p); err != nil || p.Iv == "" || p.Value == "" && !e.aead() {
+		return "", ErrInvalidPayload
+	}
+	iv, err := base64.StdEncoding.DecodeString(p.Iv)
+	if err != nil {
+		return "", ErrInvalidPayload
+	}
+	value, err := base64.StdEncoding.DecodeString(p.Value)
+	if err != nil {
+		return "", ErrInvalidPayload
+	}
+
+	if e.aead() {
+		return e.decryptGCM(iv, value, p.Tag)
+	}
+
+	if len(iv) != aes.BlockSize || len(value) == 0 || len(value)%aes.BlockSize != 0 {
+		return "", ErrInvalidPayload
+	}
+	if !hmac.Equal([]byte(e.mac(p.Iv, p.Value)), []byte(p.Mac)) {
+		return "", ErrInvalidMac
+	}
+	cipher.NewCBCDecrypter(e.block, iv).CryptBlocks(value, value)
+	padding := int(value[len(value)-1])
+	if padding == 0 || padding > aes.BlockSize || !bytes.Equal(value[len(value)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return "", ErrDecrypt
+	}
+	return string(value[:len(value)-padding]), nil
+}
+
+func (e *Encrypter) decryptGCM(iv []byte, value []byte, encodedTag string) (string, error) {
+	tag, err := base64.StdEncoding.DecodeString(encodedTag)
+	if err != nil || len(tag) != 16 || len(iv) != 12 {
+		return "", ErrInvalidPayload
+	}
+	gcm, err := cipher.NewGCM(e.block)
+	if err != nil {
+		return "", err
+	}
+	data, err := gcm.Open(nil, iv, append(value, tag...), nil)
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	return string(data), nil
+}
+
+// EncryptValue serializes the value before encryption, like encrypt does.
+func (e *Encrypter) EncryptValue(value php_serialize.PhpValue) (string, error) {
+	serialized, err := php_serialize.Serialize(value)
+	if err != nil {
+		return "", err
+	}
+	return e.Encrypt(serialized)
+}
+
+// DecryptValue unserializes decrypted value, like decrypt does.
+func (e *Encrypter) DecryptValue(encrypted string) (php_serialize.PhpValue, error) {
+	decrypted, err := e.Decrypt(encrypted)
+	if err != nil {
+		return nil, err
+	}
+	return php_serialize.UnSerialize(decrypted)
+}
+
+// mac is hex encoded HMAC-SHA256 of base64 encoded iv and value.
+func (e *Encrypter) mac(iv string, value string) string {
+	h := hmac.New(sha256.New, e.key)
+	h.Write([]byte(iv + value))
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,95 @@
+package laravel
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// the key is bytes 0..31, reference values are computed with openssl
+const testKey = "base64:AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8="
+
+func TestEncryptCBC(t *testing.T) {
+	encrypter, err := NewEncrypter(testKey, "AES-256-CBC")
+	if err != nil {
+		t.Fatalf("Can not create encrypter: %v\n", err)
+	}
+
+	iv, _ := base64.StdEncoding.DecodeString("Dw4NDAsKCQgHBgUEAwIBAA==")
+	encrypted, err := encrypter.encrypt(`s:5:"hello";`, iv)
+	if err != nil {
+		t.Fatalf("Can not encrypt value: %v\n", err)
+	}
+	decoded, _ := base64.StdEncoding.DecodeString(encrypted)
+	expected := `{"iv":"Dw4NDAsKCQgHBgUEAwIBAA==","value":"kFcGfoY5PRLNcBkz5Yl9nQ==","mac":"43100ad520265e8ed9485f05e807bf3251a66e118bd55e1bc586e87bb22a89ef","tag":""}`
+	if string(decoded) != expected {
+		t.Errorf("Value was encrypted incorrectly: %s\n", decoded)
+	}
+
+	if value, err := encrypter.DecryptValue(encrypted); err != nil || value != "hello" {
+		t.Errorf("Value was decrypted incorrectly: %#v %v\n", value, err)
+	}
+}
+
+func TestEncryptGCM(t *testing.T) {
+	encrypter, err := NewEncrypter(testKey, CIPHER_AES_256_GCM)
+	if err != nil {
+		t.Fatalf("Can not create encrypter: %v\n", err)
+	}
+
+	encrypted, err := encrypter.EncryptValue(42)
+	if err != nil {
+		t.Fatalf("Can not encrypt value: %v\n", err)
+	}
+	if value, err := encrypter.DecryptValue(encrypted); err != nil || value != 42 {
+		t.Errorf("Value was decrypted incorrectly: %#v %v\n", value, err)
+	}
+
+	var p payload
+	decoded, _ := base64.StdEncoding.DecodeString(encrypted)
+	json.Unmarshal(decoded, &p)
+	if p.Mac != "" || len(p.Iv) != 16 || len(p.Tag) != 24 {
+		t.Errorf("Payload is incorrect: %#v\n", p)
+	}
+
+	if encrypted, err = encrypter.Encrypt(""); err != nil {
+		t.Fatalf("Can not encrypt empty string: %v\n", err)
+	}
+	if value, err := encrypter.Decrypt(encrypted); err != nil || value != "" {
+		t.Errorf("Empty string was decrypted incorrectly: %q %v\n", value, err)
+	}
+
+	p.Tag = base64.StdEncoding.EncodeToString(make([]byte, 16))
+	tampered, _ := json.Marshal(p)
+	if _, err := encrypter.Decrypt(base64.StdEncoding.EncodeToString(tampered)); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Tampered value should not be decrypted: %v\n", err)
+	}
+}
+
+func TestDecryptInvalid(t *testing.T) {
+	encrypter, _ := NewEncrypter(testKey, CIPHER_AES_256_CBC)
+	other, _ := NewEncrypter("base64:"+base64.StdEncoding.EncodeToString(make([]byte, 32)), CIPHER_AES_256_CBC)
+
+	encrypted, _ := other.Encrypt("secret")
+	if _, err := encrypter.Decrypt(encrypted); !errors.Is(err, ErrInvalidMac) {
+		t.Errorf("Value encrypted with other key should not be decrypted: %v\n", err)
+	}
+	for _, encrypted := range []string{"", "!!!", base64.StdEncoding.EncodeToString([]byte(`{"iv":"","value":"x"}`))} {
+		if _, err := encrypter.Decrypt(encrypted); !errors.Is(err, ErrInvalidPayload) {
+			t.Errorf("Invalid payload %q should not be decrypted: %v\n", encrypted, err)
+		}
+	}
+}
+
+func TestNewEncrypter(t *testing.T) {
+	if _, err := NewEncrypter("0123456789abcdef", CIPHER_AES_128_CBC); err != nil {
+		t.Errorf("Raw key should be accepted: %v\n", err)
+	}
+	if _, err := NewEncrypter(testKey, CIPHER_AES_128_GCM); err == nil {
+		t.Errorf("Key of wrong length should be rejected\n")
+	}
+	if _, err := NewEncrypter(testKey, "des"); err == nil {
+		t.Errorf("Unsupported cipher should be rejected\n")
+	}
+}
@@ -0,0 +1,326 @@
+package laravel
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/solidwall/php_session_decoder"
+	"github.com/solidwall/php_session_decoder/php_serialize"
+)
+
+const (
+	KEY_TOKEN    = "_token"
+	KEY_PREVIOUS = "_previous"
+	KEY_FLASH    = "_flash"
+
+	// GUARD_CLASS is hashed into keys of authenticated users
+	GUARD_CLASS = "Illuminate\\Auth\\SessionGuard"
+
+	TOKEN_LENGTH = 40
+	TOKEN_CHARS  = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+)
+
+// Session provides access to Laravel session attributes, like Illuminate\Session\Store does.
+// Keys with dots address nested arrays the same way Laravel does.
+type Session struct {
+	session *php_session_decoder.PhpSession
+}
+
+func NewSession(session *php_session_decoder.PhpSession) *Session {
+	return &Session{session: session}
+}
+
+// PhpSession returns underlying session attributes.
+func (s *Session) PhpSession() *php_session_decoder.PhpSession {
+	return s.session
+}
+
+// Load reads the session written by Laravel, it is in php_serialize format and
+// encrypted when `encrypt` option of the session config is enabled, encrypter may be nil otherwise.
+func Load(store php_session_decoder.Store, id string, encrypter *Encrypter) (*Session, error) {
+	data, err := store.Read(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if encrypter != nil {
+		decrypted, err := encrypter.DecryptValue(data)
+		if err != nil {
+			return nil, err
+		}
+		var ok bool
+		if data, ok = decrypted.(string); !ok {
+			return nil, fmt.Errorf("laravel: unexpected session payload of type %T", decrypted)
+		}
+	}
+
+	session, err := php_session_decoder.DecodeSerialize(data)
+	if err != nil {
+		return nil, err
+	}
+	return NewSession(session), nil
+}
+
+// Save ages flash data and writes the session like Laravel does at the end of a request.
+func Save(store php_session_decoder.Store, id string, s *Session, encrypter *Encrypter) error {
+	s.AgeFlashData()
+	if encrypter != nil {
+		store = &encryptingStore{Store: store, encrypter: encrypter}
+	}
+	return php_session_decoder.Save(store, id, s.session, php_session_decoder.FORMAT_PHP_SERIALIZE)
+}
+
+// encryptingStore encrypts session data written by Save, unchanged sessions are touched as usual.
+type encryptingStore struct {
+	php_session_decoder.Store
+	encrypter *Encrypter
+}
+
+func (s *encryptingStore) Write(id string, data string) error {
+	encrypted, err := s.encrypter.EncryptValue(data)
+	if err != nil {
+		return err
+	}
+	return s.Store.Write(id, encrypted)
+}
+
+func (s *encryptingStore) Touch(id string) error {
+	if toucher, ok := s.Store.(php_session_decoder.Toucher); ok {
+		return toucher.Touch(id)
+	}
+	return nil
+}
+
+// Get returns the attribute, nested arrays are addressed by dotted keys.
+func (s *Session) Get(key string) (php_serialize.PhpValue, bool) {
+	if v, ok := s.session.Get(key); ok {
+		return v, true
+	}
+
+	parts := strings.Split(key, ".")
+	v, ok := s.session.Get(parts[0])
+	for _, part := range parts[1:] {
+		if !ok {
+			break
+		}
+		var array php_serialize.PhpArray
+		if array, ok = v.(php_serialize.PhpArray); ok {
+			v, ok = array[part]
+		}
+	}
+	return v, ok
+}
+
+// Put sets the attribute, arrays of dotted keys are created when missing.
+func (s *Session) Put(key string, value php_serialize.PhpValue) {
+	parts := strings.Split(key, ".")
+	if len(parts) == 1 {
+		s.session.Set(key, value)
+		return
+	}
+
+	root, _ := s.session.Get(parts[0])
+	root = put(root, parts[1:], value)
+	s.session.Set(parts[0], root)
+}
+
+// put returns copy of the array with the value, so the original value of the session is not mutated.
+func put(v php_serialize.PhpValue, parts []string, value php_serialize.PhpValue) php_serialize.PhpValue {
+	if len(parts) == 0 {
+		return value
+	}
+	array := php_serialize.PhpArray{}
+	if original, ok := v.(php_serialize.PhpArray); ok {
+		for k, item := range original {
+			array[k] = item
+		}
+	}
+	array[parts[0]] = put(array[parts[0]], parts[1:], value)
+	return array
+}
+
+// Forget removes the attribute, nested arrays are addressed by dotted keys.
+func (s *Session) Forget(key string) {
+	if _, ok := s.session.Get(key); ok {
+		s.session.Delete(key)
+		return
+	}
+
+	parts := strings.Split(key, ".")
+	if len(parts) == 1 {
+		return
+	}
+	parent, ok := s.Get(strings.Join(parts[:len(parts)-1], "."))
+	if array, isArray := parent.(php_serialize.PhpArray); ok && isArray {
+		if _, ok = array[parts[len(parts)-1]]; ok {
+			copied := php_serialize.PhpArray{}
+			for k, item := range array {
+				copied[k] = item
+			}
+			delete(copied, parts[len(parts)-1])
+			s.Put(strings.Join(parts[:len(parts)-1], "."), copied)
+		}
+	}
+}
+
+// Token returns CSRF token.
+func (s *Session) Token() string {
+	v, _ := s.Get(KEY_TOKEN)
+	token, _ := v.(string)
+	return token
+}
+
+func (s *Session) SetToken(token string) {
+	s.Put(KEY_TOKEN, token)
+}
+
+// RegenerateToken sets new random CSRF token like Laravel does.
+func (s *Session) RegenerateToken() (string, error) {
+	token := make([]byte, TOKEN_LENGTH)
+	max := big.NewInt(int64(len(TOKEN_CHARS)))
+	for i := range token {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		token[i] = TOKEN_CHARS[n.Int64()]
+	}
+	s.SetToken(string(token))
+	return string(token), nil
+}
+
+// PreviousUrl returns the URL of the previous request.
+func (s *Session) PreviousUrl() string {
+	v, _ := s.Get(KEY_PREVIOUS + ".url")
+	url, _ := v.(string)
+	return url
+}
+
+func (s *Session) SetPreviousUrl(url string) {
+	s.Put(KEY_PREVIOUS+".url", url)
+}
+
+// GuardKey returns the key of user id authenticated by the guard, `login_web_<sha1>` for the web guard.
+func GuardKey(guard string) string {
+	hash := sha1.Sum([]byte(GUARD_CLASS))
+	return "login_" + guard + "_" + hex.EncodeToString(hash[:])
+}
+
+// UserId returns id of the user authenticated by the guard.
+func (s *Session) UserId(guard string) (php_serialize.PhpValue, bool) {
+	return s.session.Get(GuardKey(guard))
+}
+
+func (s *Session) SetUserId(guard string, id php_serialize.PhpValue) {
+	s.session.Set(GuardKey(guard), id)
+}
+
+// Logout forgets the user of the guard and its password hash.
+func (s *Session) Logout(guard string) {
+	s.session.Delete(GuardKey(guard))
+	s.session.Delete("password_hash_" + guard)
+}
+
+// Flash puts the value which is available in the current and the next request.
+func (s *Session) Flash(key string, value php_serialize.PhpValue) {
+	s.Put(key, value)
+	s.mergeFlashes("new", []string{key})
+	s.removeFlashes("old", []string{key})
+}
+
+// Now puts the value which is available in the current request only.
+func (s *Session) Now(key string, value php_serialize.PhpValue) {
+	s.Put(key, value)
+	s.mergeFlashes("old", []string{key})
+}
+
+// Reflash keeps all flash data for the next request.
+func (s *Session) Reflash() {
+	s.mergeFlashes("new", s.flashKeys("old"))
+	s.Put(KEY_FLASH+".old", php_serialize.PhpArray{})
+}
+
+// Keep keeps flash data of given keys for the next request.
+func (s *Session) Keep(keys ...string) {
+	s.mergeFlashes("new", keys)
+	s.removeFlashes("old", keys)
+}
+
+// FlashKeys returns keys of flash data flashed in the previous request and in the current one.
+func (s *Session) FlashKeys() (old []string, new []string) {
+	return s.flashKeys("old"), s.flashKeys("new")
+}
+
+// AgeFlashData forgets flash data of the previous request and makes flash data
+// of the current request old, Laravel does it when the session is saved.
+func (s *Session) AgeFlashData() {
+	for _, key := range s.flashKeys("old") {
+		s.Forget(key)
+	}
+	s.Put(KEY_FLASH+".old", listOf(s.flashKeys("new")))
+	s.Put(KEY_FLASH+".new", php_serialize.PhpArray{})
+}
+
+func (s *Session) flashKeys(bag string) []string {
+	v, _ := s.Get(KEY_FLASH + "." + bag)
+	array, _ := v.(php_serialize.PhpArray)
+
+	indexes := make([]int, 0, len(array))
+	for k := range array {
+		if i, ok := k.(int); ok {
+			indexes = append(indexes, i)
+		}
+	}
+	sort.Ints(indexes)
+
+	keys := make([]string, 0, len(indexes))
+	for _, i := range indexes {
+		if key, ok := array[i].(string); ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (s *Session) mergeFlashes(bag string, keys []string) {
+	merged := s.flashKeys(bag)
+	for _, key := range keys {
+		if !contains(merged, key) {
+			merged = append(merged, key)
+		}
+	}
+	s.Put(KEY_FLASH+"."+bag, listOf(merged))
+}
+
+func (s *Session) removeFlashes(bag string, keys []string) {
+	var kept []string
+	for _, key := range s.flashKeys(bag) {
+		if !contains(keys, key) {
+			kept = append(kept, key)
+		}
+	}
+	s.Put(KEY_FLASH+"."+bag, listOf(kept))
+}
+
+// listOf converts keys to PHP list, which is decoded back to the same value.
+func listOf(keys []string) php_serialize.PhpArray {
+	list := make(php_serialize.PhpArray, len(keys))
+	for i, key := range keys {
+		list[i] = key
+	}
+	return list
+}
+
+func contains(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
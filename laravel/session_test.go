@@ -0,0 +1,154 @@
+package laravel
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/solidwall/php_session_decoder"
+	"github.com/solidwall/php_session_decoder/php_serialize"
+)
+
+const testPayload = `a:5:{s:6:"_token";s:40:"GQz3Tnm7ULNCkrtvPvZJ52Xc3CjCm2GkOJ9Zm1iQ";` +
+	`s:9:"_previous";a:1:{s:3:"url";s:22:"http://localhost/login";}` +
+	`s:6:"_flash";a:2:{s:3:"old";a:1:{i:0;s:6:"status";}s:3:"new";a:0:{}}` +
+	`s:6:"status";s:5:"Saved";s:50:"login_web_59ba36addc2b2f9401580f014c7f58ea4e30989d";i:7;}`
+
+type memoryStore struct {
+	sessions map[string]string
+	writes   int
+}
+
+func (s *memoryStore) Read(id string) (string, error) {
+	data, ok := s.sessions[id]
+	if !ok {
+		return "", php_session_decoder.ErrNotFound
+	}
+	return data, nil
+}
+
+func (s *memoryStore) Write(id string, data string) error {
+	s.sessions[id] = data
+	s.writes++
+	return nil
+}
+
+func (s *memoryStore) Destroy(id string) error {
+	delete(s.sessions, id)
+	return nil
+}
+
+func TestSession(t *testing.T) {
+	store := &memoryStore{sessions: map[string]string{"abc": testPayload}}
+	session, err := Load(store, "abc", nil)
+	if err != nil {
+		t.Fatalf("Can not load session: %v\n", err)
+	}
+
+	if token := session.Token(); token != "GQz3Tnm7ULNCkrtvPvZJ52Xc3CjCm2GkOJ9Zm1iQ" {
+		t.Errorf("Token is incorrect: %q\n", token)
+	}
+	if url := session.PreviousUrl(); url != "http://localhost/login" {
+		t.Errorf("Previous URL is incorrect: %q\n", url)
+	}
+	if id, ok := session.UserId("web"); !ok || id != 7 {
+		t.Errorf("User id is incorrect: %#v\n", id)
+	}
+	if _, ok := session.UserId("admin"); ok {
+		t.Errorf("User of other guard should not be authenticated\n")
+	}
+	if status, _ := session.Get("status"); status != "Saved" {
+		t.Errorf("Flash data is incorrect: %#v\n", status)
+	}
+
+	session.Flash("message", "Hello")
+	if old, new := session.FlashKeys(); !reflect.DeepEqual(old, []string{"status"}) || !reflect.DeepEqual(new, []string{"message"}) {
+		t.Errorf("Flash keys are incorrect: %v %v\n", old, new)
+	}
+	if err = Save(store, "abc", session, nil); err != nil {
+		t.Fatalf("Can not save session: %v\n", err)
+	}
+
+	session, err = Load(store, "abc", nil)
+	if err != nil {
+		t.Fatalf("Can not load saved session: %v\n", err)
+	}
+	if _, ok := session.Get("status"); ok {
+		t.Errorf("Old flash data was not forgotten\n")
+	}
+	if message, _ := session.Get("message"); message != "Hello" {
+		t.Errorf("New flash data is lost: %#v\n", message)
+	}
+	if old, new := session.FlashKeys(); !reflect.DeepEqual(old, []string{"message"}) || len(new) != 0 {
+		t.Errorf("Flash data was aged incorrectly: %v %v\n", old, new)
+	}
+
+	session.Keep("message")
+	session.Logout("web")
+	if _, ok := session.UserId("web"); ok {
+		t.Errorf("User was not logged out\n")
+	}
+	session.AgeFlashData()
+	if message, _ := session.Get("message"); message != "Hello" {
+		t.Errorf("Kept flash data is lost: %#v\n", message)
+	}
+}
+
+func TestSessionUnchanged(t *testing.T) {
+	store := &memoryStore{sessions: map[string]string{"abc": `a:2:{s:6:"_token";s:3:"xyz";s:6:"_flash";a:2:{s:3:"old";a:0:{}s:3:"new";a:0:{}}}`}}
+	session, err := Load(store, "abc", nil)
+	if err != nil {
+		t.Fatalf("Can not load session: %v\n", err)
+	}
+	if err = Save(store, "abc", session, nil); err != nil {
+		t.Errorf("Can not save session: %v\n", err)
+	}
+	if store.writes != 0 {
+		t.Errorf("Session without flash data should not be written\n")
+	}
+}
+
+func TestSessionNested(t *testing.T) {
+	session := NewSession(php_session_decoder.NewPhpSession())
+	session.Put("url.intended", "http://localhost/home")
+	session.SetPreviousUrl("http://localhost/")
+
+	if v, _ := session.Get("url"); !reflect.DeepEqual(v, php_serialize.PhpArray{"intended": "http://localhost/home"}) {
+		t.Errorf("Nested value was put incorrectly: %#v\n", v)
+	}
+	session.Forget("url.intended")
+	if _, ok := session.Get("url.intended"); ok {
+		t.Errorf("Nested value was not forgotten\n")
+	}
+
+	token, err := session.RegenerateToken()
+	if err != nil || len(token) != TOKEN_LENGTH || session.Token() != token {
+		t.Errorf("Token was regenerated incorrectly: %q %v\n", token, err)
+	}
+	if key := GuardKey("web"); key != "login_web_59ba36addc2b2f9401580f014c7f58ea4e30989d" {
+		t.Errorf("Guard key is incorrect: %q\n", key)
+	}
+}
+
+func TestSessionEncrypted(t *testing.T) {
+	encrypter, _ := NewEncrypter(testKey, CIPHER_AES_256_CBC)
+	store := &memoryStore{sessions: map[string]string{}}
+
+	session := NewSession(php_session_decoder.NewPhpSession())
+	session.SetUserId("web", 7)
+	if err := Save(store, "abc", session, encrypter); err != nil {
+		t.Fatalf("Can not save session: %v\n", err)
+	}
+	if value, err := encrypter.DecryptValue(store.sessions["abc"]); err != nil {
+		t.Errorf("Session was not encrypted: %v\n", err)
+	} else if _, ok := value.(string); !ok {
+		t.Errorf("Serialized session should be encrypted as string: %#v\n", value)
+	}
+
+	loaded, err := Load(store, "abc", encrypter)
+	if err != nil {
+		t.Fatalf("Can not load session: %v\n", err)
+	}
+	if id, _ := loaded.UserId("web"); id != 7 {
+		t.Errorf("Session was loaded incorrectly: %#v\n", id)
+	}
+}
@@ -0,0 +1,345 @@
+package php_session_decoder
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+var (
+	ErrLockTimeout = errors.New("php_session: Unable to acquire session lock")
+	ErrConflict    = errors.New("php_session: Session was modified concurrently")
+)
+
+// Locker is implemented by stores which take the same session locks as PHP save handlers.
+type Locker interface {
+	Lock(id string) error
+	Unlock(id string) error
+}
+
+// TryLocker is implemented by Lockers which can take the lock without waiting for it.
+// TryLock returns false when the session is locked by somebody else.
+type TryLocker interface {
+	TryLock(id string) (bool, error)
+}
+
+// HandleLocker is implemented by stores whose lock belongs to the returned Handle, like flock
+// of the files store. Read and Write of such store wait while the session is locked, so
+// the lock holder reads and writes the session through the handle.
+type HandleLocker interface {
+	LockHandle(id string) (Handle, error)
+	// TryLockHandle returns nil Handle when the session is locked by somebody else.
+	TryLockHandle(id string) (Handle, error)
+}
+
+// Handle reads and writes the locked session, Close releases the lock.
+type Handle interface {
+	Read() (string, error)
+	Write(data string) error
+	Close() error
+}
+
+// LOCK_POLL_INTERVAL is the delay between attempts of TryLocker and HandleLocker to take the lock.
+const LOCK_POLL_INTERVAL = 10 * time.Millisecond
+
+// Swapper is implemented by stores which can replace session data atomically.
+// CompareAndSwap returns ErrConflict when the stored data is not old anymore,
+// missing session is the same as empty data.
+type Swapper interface {
+	CompareAndSwap(id string, old string, new string) error
+}
+
+// Lock takes the session lock of the store waiting for it at most timeout,
+// zero timeout waits as long as the store does. Stores without Locker are
+// locked only within this process, PHP requests are not blocked by such lock
+// and other stores don't share it.
+// TryLocker and HandleLocker are polled until the timeout, while Locker.Lock of other
+// stores can't be cancelled: it keeps waiting after the timeout and the lock is released once taken.
+// The session has to be read and written through the returned store until
+// the returned function releases the lock.
+func Lock(store Store, id string, timeout time.Duration) (locked Store, unlock func() error, err error) {
+	if locker, ok := store.(HandleLocker); ok {
+		handle, err := lockHandle(locker, id, timeout)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &handleStore{Store: store, id: id, handle: handle}, handle.Close, nil
+	}
+
+	locker, ok := store.(Locker)
+	if !ok {
+		if unlock, err = processLocks.lock(store, id, timeout); err != nil {
+			return nil, nil, err
+		}
+		return store, unlock, nil
+	}
+
+	if err = lockTimeout(locker, id, timeout); err != nil {
+		return nil, nil, err
+	}
+	return store, func() error { return locker.Unlock(id) }, nil
+}
+
+// Update reads the session under the lock, lets fn modify it and saves it
+// when fn returns nil. Missing session is passed to fn as empty one.
+// The lock is released in any case, even when fn panics.
+func Update(store Store, id string, format Format, timeout time.Duration, fn func(session *PhpSession) error) (err error) {
+	store, unlock, err := Lock(store, id, timeout)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if unlockErr := unlock(); err == nil {
+			err = unlockErr
+		}
+	}()
+
+	session, err := readSession(store, id, format)
+	if err != nil {
+		return err
+	}
+	if err = fn(session); err != nil {
+		return err
+	}
+	return Save(store, id, session, format)
+}
+
+// UpdateOptimistic reads the session without the lock, lets fn modify it and
+// writes it back with CompareAndSwap. ErrConflict is returned when the session
+// was changed meanwhile, the whole update can be retried then.
+func UpdateOptimistic(store Store, id string, format Format, fn func(session *PhpSession) error) error {
+	data, err := store.Read(id)
+	if errors.Is(err, ErrNotFound) {
+		data, err = "", nil
+	}
+	if err != nil {
+		return err
+	}
+	session, err := DecodeFormat(data, format)
+	if err != nil {
+		return err
+	}
+
+	if err = fn(session); err != nil {
+		return err
+	}
+	defer session.release()
+	if !session.compare() {
+		return nil
+	}
+	encoded, err := EncodeFormat(session, format)
+	if err != nil {
+		return err
+	}
+	return CompareAndSwap(store, id, data, encoded)
+}
+
+// CompareAndSwap writes new data only if the stored data is still old.
+// Stores without Swapper are compared and written under Lock, so it must
+// not be called while the lock of the session is held.
+func CompareAndSwap(store Store, id string, old string, new string) (err error) {
+	if swapper, ok := store.(Swapper); ok {
+		return swapper.CompareAndSwap(id, old, new)
+	}
+
+	store, unlock, err := Lock(store, id, 0)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if unlockErr := unlock(); err == nil {
+			err = unlockErr
+		}
+	}()
+
+	current, err := store.Read(id)
+	if errors.Is(err, ErrNotFound) {
+		current, err = "", nil
+	}
+	if err != nil {
+		return err
+	}
+	if current != old {
+		return fmt.Errorf("%w: %q", ErrConflict, id)
+	}
+	return store.Write(id, new)
+}
+
+// readSession decodes stored session, missing one is empty.
+func readSession(store Store, id string, format Format) (*PhpSession, error) {
+	data, err := store.Read(id)
+	if errors.Is(err, ErrNotFound) {
+		return NewPhpSession(), nil
+	} else if err != nil {
+		return nil, err
+	}
+	return DecodeFormat(data, format)
+}
+
+// lockTimeout stops waiting for the store lock after timeout,
+// the lock taken after that is released right away.
+func lockTimeout(locker Locker, id string, timeout time.Duration) error {
+	if timeout <= 0 {
+		return locker.Lock(id)
+	}
+	if tryLocker, ok := locker.(TryLocker); ok {
+		return pollLock(tryLocker, id, timeout)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- locker.Lock(id)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		go func() {
+			if err := <-done; err == nil {
+				locker.Unlock(id)
+			}
+		}()
+		return fmt.Errorf("%w %q: timed out after %v", ErrLockTimeout, id, timeout)
+	}
+}
+
+// pollLock tries to take the lock until timeout, nothing keeps waiting for the lock after it.
+func pollLock(locker TryLocker, id string, timeout time.Duration) error {
+	return poll(id, timeout, func() (bool, error) {
+		return locker.TryLock(id)
+	})
+}
+
+// lockHandle takes the lock of HandleLocker, it is polled when timeout is set.
+func lockHandle(locker HandleLocker, id string, timeout time.Duration) (handle Handle, err error) {
+	if timeout <= 0 {
+		return locker.LockHandle(id)
+	}
+	err = poll(id, timeout, func() (bool, error) {
+		handle, err = locker.TryLockHandle(id)
+		return handle != nil, err
+	})
+	return handle, err
+}
+
+// poll calls try until it takes the lock or timeout expires.
+func poll(id string, timeout time.Duration, try func() (bool, error)) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		locked, err := try()
+		if err != nil || locked {
+			return err
+		}
+
+		left := time.Until(deadline)
+		if left <= 0 {
+			return fmt.Errorf("%w %q: timed out after %v", ErrLockTimeout, id, timeout)
+		}
+		if left > LOCK_POLL_INTERVAL {
+			left = LOCK_POLL_INTERVAL
+		}
+		time.Sleep(left)
+	}
+}
+
+// handleStore reads and writes the session locked by HandleLocker through its handle.
+type handleStore struct {
+	Store
+	id     string
+	handle Handle
+}
+
+func (hs *handleStore) Read(id string) (string, error) {
+	if id == hs.id {
+		return hs.handle.Read()
+	}
+	return hs.Store.Read(id)
+}
+
+func (hs *handleStore) Write(id string, data string) error {
+	if id == hs.id {
+		return hs.handle.Write(data)
+	}
+	return hs.Store.Write(id, data)
+}
+
+func (hs *handleStore) Touch(id string) error {
+	if toucher, ok := hs.Store.(Toucher); ok {
+		return toucher.Touch(id)
+	}
+	return nil
+}
+
+// processLocks locks sessions of stores without Locker.
+var processLocks = &localLocks{locks: map[lockKey]*localLock{}}
+
+type localLocks struct {
+	mu    sync.Mutex
+	locks map[lockKey]*localLock
+}
+
+// lockKey separates sessions of different stores with the same id.
+type lockKey struct {
+	store Store
+	id    string
+}
+
+func newLockKey(store Store, id string) lockKey {
+	if !reflect.TypeOf(store).Comparable() {
+		// such stores can't be told apart, their sessions are locked by id only
+		store = nil
+	}
+	return lockKey{store: store, id: id}
+}
+
+type localLock struct {
+	ch   chan struct{}
+	refs int
+}
+
+func (ll *localLocks) lock(store Store, id string, timeout time.Duration) (func() error, error) {
+	key := newLockKey(store, id)
+	ll.mu.Lock()
+	lock, ok := ll.locks[key]
+	if !ok {
+		lock = &localLock{ch: make(chan struct{}, 1)}
+		ll.locks[key] = lock
+	}
+	lock.refs++
+	ll.mu.Unlock()
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case lock.ch <- struct{}{}:
+		var once sync.Once
+		return func() error {
+			once.Do(func() {
+				<-lock.ch
+				ll.release(key, lock)
+			})
+			return nil
+		}, nil
+	case <-expired:
+		ll.release(key, lock)
+		return nil, fmt.Errorf("%w %q: timed out after %v", ErrLockTimeout, id, timeout)
+	}
+}
+
+func (ll *localLocks) release(key lockKey, lock *localLock) {
+	ll.mu.Lock()
+	defer ll.mu.Unlock()
+	if lock.refs--; lock.refs == 0 {
+		delete(ll.locks, key)
+	}
+}
@@ -0,0 +1,227 @@
+package php_session_decoder
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memoryStore struct {
+	mu       sync.Mutex
+	sessions map[string]string
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{sessions: map[string]string{}}
+}
+
+func (s *memoryStore) Read(id string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.sessions[id]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return data, nil
+}
+
+func (s *memoryStore) Write(id string, data string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[id] = data
+	return nil
+}
+
+func (s *memoryStore) Destroy(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, id)
+	return nil
+}
+
+// lockingStore blocks in Lock like PHP save handlers do.
+type lockingStore struct {
+	*memoryStore
+	lock     chan struct{}
+	unlocked chan struct{}
+}
+
+func newLockingStore() *lockingStore {
+	return &lockingStore{memoryStore: newMemoryStore(), lock: make(chan struct{}, 1), unlocked: make(chan struct{}, 100)}
+}
+
+func (s *lockingStore) Lock(id string) error {
+	s.lock <- struct{}{}
+	return nil
+}
+
+func (s *lockingStore) Unlock(id string) error {
+	<-s.lock
+	s.unlocked <- struct{}{}
+	return nil
+}
+
+// tryLockingStore takes the lock without waiting, Lock should not be used with timeout.
+type tryLockingStore struct {
+	*lockingStore
+}
+
+func (s *tryLockingStore) Lock(id string) error {
+	panic("Lock should not be called with timeout")
+}
+
+func (s *tryLockingStore) TryLock(id string) (bool, error) {
+	select {
+	case s.lock <- struct{}{}:
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+func increment(session *PhpSession) error {
+	v, _ := session.Get("counter")
+	counter, _ := v.(int)
+	session.Set("counter", counter+1)
+	return nil
+}
+
+func TestUpdate(t *testing.T) {
+	for _, store := range []Store{newMemoryStore(), newLockingStore()} {
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := Update(store, "abc", FORMAT_PHP, 0, increment); err != nil {
+					t.Errorf("Can not update session: %v\n", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if data, _ := store.Read("abc"); data != "counter|i:20;" {
+			t.Errorf("Concurrent updates were lost with %T: %q\n", store, data)
+		}
+	}
+}
+
+func TestUpdateReleasesLock(t *testing.T) {
+	store := newMemoryStore()
+	store.sessions["abc"] = "a|i:1;"
+
+	failure := errors.New("failure")
+	err := Update(store, "abc", FORMAT_PHP, 0, func(session *PhpSession) error {
+		session.Set("a", 2)
+		return failure
+	})
+	if err != failure {
+		t.Errorf("Error of update was not returned: %v\n", err)
+	}
+	if store.sessions["abc"] != "a|i:1;" {
+		t.Errorf("Failed update should not be written: %q\n", store.sessions["abc"])
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Panic should not be recovered\n")
+			}
+		}()
+		Update(store, "abc", FORMAT_PHP, 0, func(session *PhpSession) error {
+			panic("failure")
+		})
+	}()
+
+	if err = Update(store, "abc", FORMAT_PHP, time.Second, increment); err != nil {
+		t.Errorf("Lock was not released: %v\n", err)
+	}
+}
+
+func TestLockTimeout(t *testing.T) {
+	stores := []Store{newMemoryStore(), newLockingStore()}
+	for _, store := range stores {
+		_, unlock, err := Lock(store, "abc", 0)
+		if err != nil {
+			t.Fatalf("Can not lock session: %v\n", err)
+		}
+		if _, _, err = Lock(store, "abc", 10*time.Millisecond); !errors.Is(err, ErrLockTimeout) {
+			t.Errorf("Locked session should not be locked again with %T: %v\n", store, err)
+		}
+		if err = unlock(); err != nil {
+			t.Errorf("Can not unlock session: %v\n", err)
+		}
+	}
+
+	// the lock taken by the store after the timeout is released
+	store := stores[1].(*lockingStore)
+	select {
+	case <-store.unlocked:
+		<-store.unlocked
+	case <-time.After(time.Second):
+		t.Errorf("Lock taken after the timeout was not released\n")
+	}
+	// polling leaves nothing waiting for the lock after the timeout
+	tryStore := &tryLockingStore{newLockingStore()}
+	_, unlock, err := Lock(tryStore, "abc", time.Second)
+	if err != nil {
+		t.Fatalf("Can not lock session: %v\n", err)
+	}
+	if _, _, err = Lock(tryStore, "abc", 30*time.Millisecond); !errors.Is(err, ErrLockTimeout) {
+		t.Errorf("Locked session should not be locked again: %v\n", err)
+	}
+	unlock()
+	time.Sleep(30 * time.Millisecond)
+	if len(tryStore.lock) != 0 {
+		t.Errorf("Lock was taken after the timeout\n")
+	}
+
+	processLocks.mu.Lock()
+	if len(processLocks.locks) != 0 {
+		t.Errorf("Process locks were not released: %v\n", processLocks.locks)
+	}
+	processLocks.mu.Unlock()
+}
+
+func TestProcessLocksPerStore(t *testing.T) {
+	store, other := newMemoryStore(), newMemoryStore()
+	_, unlock, err := Lock(store, "abc", 0)
+	if err != nil {
+		t.Fatalf("Can not lock session: %v\n", err)
+	}
+	defer unlock()
+
+	if _, unlockOther, err := Lock(other, "abc", 10*time.Millisecond); err != nil {
+		t.Errorf("Session of other store should not be locked: %v\n", err)
+	} else {
+		unlockOther()
+	}
+	if _, _, err = Lock(store, "abc", 10*time.Millisecond); !errors.Is(err, ErrLockTimeout) {
+		t.Errorf("Locked session should not be locked again: %v\n", err)
+	}
+}
+
+func TestUpdateOptimistic(t *testing.T) {
+	store := newMemoryStore()
+	if err := UpdateOptimistic(store, "abc", FORMAT_PHP, increment); err != nil {
+		t.Errorf("Can not update missing session: %v\n", err)
+	}
+
+	err := UpdateOptimistic(store, "abc", FORMAT_PHP, func(session *PhpSession) error {
+		store.Write("abc", "counter|i:5;")
+		return increment(session)
+	})
+	if !errors.Is(err, ErrConflict) {
+		t.Errorf("Concurrent change was not detected: %v\n", err)
+	}
+	if data := store.sessions["abc"]; data != "counter|i:5;" {
+		t.Errorf("Concurrent change was overwritten: %q\n", data)
+	}
+
+	if err = CompareAndSwap(store, "abc", "counter|i:5;", "counter|i:6;"); err != nil {
+		t.Errorf("Can not swap session: %v\n", err)
+	} else if data := store.sessions["abc"]; data != "counter|i:6;" {
+		t.Errorf("Session was swapped incorrectly: %q\n", data)
+	}
+}
@@ -0,0 +1,2 @@
+// Package memcached provides session store compatible with php-memcached session handler.
+package memcached
@@ -0,0 +1,126 @@
+package memcached
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeServer is local stand-in of memcached server which supports commands used by Store.
+type fakeServer struct {
+	listener net.Listener
+
+	mu      sync.Mutex
+	items   map[string]item
+	exptime map[string]int64
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &fakeServer{
+		listener: listener,
+		items:    make(map[string]item),
+		exptime:  make(map[string]int64),
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			c, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(c)
+		}
+	}()
+	return server
+}
+
+func (fs *fakeServer) addr() string {
+	return fs.listener.Addr().String()
+}
+
+func (fs *fakeServer) get(key string) (item, int64, bool) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	v, ok := fs.items[key]
+	return v, fs.exptime[key], ok
+}
+
+func (fs *fakeServer) set(key string, value item) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.items[key] = value
+}
+
+func (fs *fakeServer) serve(c net.Conn) {
+	defer c.Close()
+	r := bufio.NewReader(c)
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			return
+		}
+
+		var data []byte
+		if fields[0] == "set" || fields[0] == "add" {
+			size, _ := strconv.Atoi(fields[4])
+			data = make([]byte, size+2)
+			if _, err = io.ReadFull(r, data); err != nil {
+				return
+			}
+			data = data[:size]
+		}
+		io.WriteString(c, fs.execute(fields, data))
+	}
+}
+
+func (fs *fakeServer) execute(fields []string, data []byte) string {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	key := fields[1]
+	_, exists := fs.items[key]
+	switch fields[0] {
+	case "get":
+		if !exists {
+			return "END\r\n"
+		}
+		v := fs.items[key]
+		return fmt.Sprintf("VALUE %s %d %d\r\n%s\r\nEND\r\n", key, v.flags, len(v.value), v.value)
+	case "add", "set":
+		if fields[0] == "add" && exists {
+			return "NOT_STORED\r\n"
+		}
+		flags, _ := strconv.ParseUint(fields[2], 10, 32)
+		fs.items[key] = item{value: data, flags: uint32(flags)}
+		fs.exptime[key], _ = strconv.ParseInt(fields[3], 10, 64)
+		return "STORED\r\n"
+	case "touch":
+		if !exists {
+			return "NOT_FOUND\r\n"
+		}
+		fs.exptime[key], _ = strconv.ParseInt(fields[2], 10, 64)
+		return "TOUCHED\r\n"
+	case "delete":
+		if !exists {
+			return "NOT_FOUND\r\n"
+		}
+		delete(fs.items, key)
+		return "DELETED\r\n"
+	}
+	return "ERROR\r\n"
+}
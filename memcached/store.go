@@ -0,0 +1,243 @@
+package memcached
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/solidwall/php_session_decoder"
+)
+
+const (
+	KEY_PREFIX_DEFAULT = "memc.sess.key."
+	LOCK_PREFIX        = "lock."
+
+	// flags of php-memcached values, the lower 4 bits hold the type
+	FLAG_TYPE_MASK        uint32 = 0xf
+	FLAG_TYPE_STRING      uint32 = 0
+	FLAG_COMPRESSED       uint32 = 1 << 4
+	FLAG_COMPRESSION_ZLIB uint32 = 1 << 5
+
+	// memcached treats expiration longer than 30 days as unix timestamp
+	maxRelativeExpiration = 60 * 60 * 24 * 30
+	maxKeyLength          = 250
+)
+
+var ErrLockTimeout = fmt.Errorf("memcached: %w", php_session_decoder.ErrLockTimeout)
+
+// Options mirror php.ini settings of php-memcached session handler, zero values are replaced with PHP defaults.
+type Options struct {
+	Network string
+	Addr    string
+	Timeout time.Duration
+	// Prefix is memcached.sess_prefix.
+	Prefix string
+	// Lifetime is session.gc_maxlifetime, expiration of session items.
+	Lifetime time.Duration
+	// LockExpire is memcached.sess_lock_expire, expiration of lock items.
+	LockExpire time.Duration
+	// LockWaitMin and LockWaitMax are memcached.sess_lock_wait_min and memcached.sess_lock_wait_max,
+	// the delay between attempts to take a lock starts from the min and is doubled up to the max.
+	LockWaitMin time.Duration
+	LockWaitMax time.Duration
+	// LockRetries is memcached.sess_lock_retries.
+	LockRetries int
+}
+
+// Store reads and writes sessions the same way php-memcached does: data is stored in
+// `<prefix><id>` items and locks in `lock.<prefix><id>` items.
+type Store struct {
+	options Options
+
+	mu   sync.Mutex
+	conn *conn
+}
+
+var (
+	_ php_session_decoder.Store   = (*Store)(nil)
+	_ php_session_decoder.Locker  = (*Store)(nil)
+	_ php_session_decoder.Toucher = (*Store)(nil)
+)
+
+func NewStore(options Options) *Store {
+	if options.Network == "" {
+		options.Network = "tcp"
+	}
+	if options.Addr == "" {
+		options.Addr = "127.0.0.1:11211"
+	}
+	if options.Prefix == "" {
+		options.Prefix = KEY_PREFIX_DEFAULT
+	}
+	if options.Lifetime == 0 {
+		options.Lifetime = 1440 * time.Second
+	}
+	if options.LockExpire == 0 {
+		options.LockExpire = 30 * time.Second
+	}
+	if options.LockWaitMin == 0 {
+		options.LockWaitMin = 150 * time.Millisecond
+	}
+	if options.LockWaitMax == 0 {
+		options.LockWaitMax = 150 * time.Millisecond
+	}
+	if options.LockRetries == 0 {
+		options.LockRetries = 5
+	}
+
+	return &Store{
+		options: options,
+	}
+}
+
+// Key returns memcached key of the session.
+func (s *Store) Key(id string) string {
+	return s.options.Prefix + id
+}
+
+func (s *Store) Read(id string) (string, error) {
+	var res *item
+	err := s.do(id, func(c *conn, key string) (err error) {
+		res, err = c.get(key)
+		return
+	})
+	if err != nil {
+		return "", err
+	}
+	if res == nil {
+		return "", php_session_decoder.ErrNotFound
+	}
+	return decodeValue(res)
+}
+
+func (s *Store) Write(id string, data string) error {
+	return s.do(id, func(c *conn, key string) error {
+		return c.store("set", key, []byte(data), FLAG_TYPE_STRING, expiration(s.options.Lifetime))
+	})
+}
+
+// Touch updates expiration of the session without writing it.
+func (s *Store) Touch(id string) error {
+	return s.do(id, func(c *conn, key string) error {
+		return c.touch(key, expiration(s.options.Lifetime))
+	})
+}
+
+func (s *Store) Destroy(id string) error {
+	return s.do(id, func(c *conn, key string) error {
+		return c.delete(key)
+	})
+}
+
+// Lock takes session lock, the delay between attempts grows from LockWaitMin to LockWaitMax.
+func (s *Store) Lock(id string) error {
+	wait := s.options.LockWaitMin
+	for i := 0; i < s.options.LockRetries; i++ {
+		err := s.do(id, func(c *conn, key string) error {
+			return c.store("add", LOCK_PREFIX+key, []byte("1"), FLAG_TYPE_STRING, expiration(s.options.LockExpire))
+		})
+		if err != errNotStored {
+			return err
+		}
+
+		time.Sleep(wait)
+		if wait *= 2; wait > s.options.LockWaitMax {
+			wait = s.options.LockWaitMax
+		}
+	}
+	return ErrLockTimeout
+}
+
+func (s *Store) Unlock(id string) error {
+	return s.do(id, func(c *conn, key string) error {
+		return c.delete(LOCK_PREFIX + key)
+	})
+}
+
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.close()
+	s.conn = nil
+	return err
+}
+
+// do runs f over the shared connection, which is reopened after network errors.
+func (s *Store) do(id string, f func(c *conn, key string) error) error {
+	key := s.Key(id)
+	if len(LOCK_PREFIX+key) > maxKeyLength {
+		return fmt.Errorf("memcached: key %q is too long", key)
+	}
+	for _, c := range key {
+		if c <= ' ' || c == 0x7f {
+			return fmt.Errorf("memcached: key %q contains illegal char %q", key, c)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		c, err := dial(s.options.Network, s.options.Addr, s.options.Timeout)
+		if err != nil {
+			return err
+		}
+		s.conn = c
+	}
+
+	err := f(s.conn, key)
+	if _, ok := err.(Error); err != nil && err != errNotStored && !ok {
+		s.conn.close()
+		s.conn = nil
+	}
+	return err
+}
+
+func expiration(d time.Duration) int64 {
+	seconds := int64(d / time.Second)
+	if seconds > maxRelativeExpiration {
+		return time.Now().Unix() + seconds
+	}
+	return seconds
+}
+
+// decodeValue handles flags php-memcached sets for values stored by Memcached::set.
+func decodeValue(res *item) (string, error) {
+	if res.flags&FLAG_TYPE_MASK != FLAG_TYPE_STRING {
+		return "", fmt.Errorf("memcached: unsupported type %d of value", res.flags&FLAG_TYPE_MASK)
+	}
+	if res.flags&FLAG_COMPRESSED == 0 {
+		return string(res.value), nil
+	}
+	if res.flags&FLAG_COMPRESSION_ZLIB == 0 {
+		return "", fmt.Errorf("memcached: unsupported compression of value, only zlib is supported")
+	}
+
+	// compressed value starts with its original length
+	if len(res.value) < 4 {
+		return "", fmt.Errorf("memcached: compressed value is too short")
+	}
+	length := binary.LittleEndian.Uint32(res.value)
+	r, err := zlib.NewReader(bytes.NewReader(res.value[4:]))
+	if err != nil {
+		return "", fmt.Errorf("memcached: unable to decompress value: %v", err)
+	}
+	defer r.Close()
+
+	buf := bytes.NewBuffer([]byte{})
+	if _, err = io.Copy(buf, r); err != nil {
+		return "", fmt.Errorf("memcached: unable to decompress value: %v", err)
+	}
+	if buf.Len() != int(length) {
+		return "", fmt.Errorf("memcached: decompressed value has %d bytes instead of %d", buf.Len(), length)
+	}
+	return buf.String(), nil
+}
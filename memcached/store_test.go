@@ -0,0 +1,120 @@
+package memcached
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/solidwall/php_session_decoder"
+)
+
+func TestReadWrite(t *testing.T) {
+	server := newFakeServer(t)
+	store := NewStore(Options{Addr: server.addr()})
+	defer store.Close()
+
+	if _, err := store.Read("abc"); !errors.Is(err, php_session_decoder.ErrNotFound) {
+		t.Errorf("Missing session should not be found, have got: %v\n", err)
+	}
+
+	session := php_session_decoder.NewPhpSession().Set("user_id", 42)
+	if err := php_session_decoder.Save(store, "abc", session, php_session_decoder.FORMAT_PHP); err != nil {
+		t.Errorf("Can not save session: %v\n", err)
+	}
+	if v, exptime, _ := server.get("memc.sess.key.abc"); string(v.value) != "user_id|i:42;" || v.flags != 0 || exptime != 1440 {
+		t.Errorf("Session was written incorrectly: %q, flags %d, exptime %d\n", v.value, v.flags, exptime)
+	}
+
+	if result, err := php_session_decoder.Load(store, "abc", php_session_decoder.FORMAT_PHP); err != nil {
+		t.Errorf("Can not load session: %v\n", err)
+	} else if v, _ := result.Get("user_id"); v != 42 {
+		t.Errorf("Session was loaded incorrectly: %#v\n", result)
+	}
+
+	if err := store.Destroy("abc"); err != nil {
+		t.Errorf("Can not destroy session: %v\n", err)
+	}
+	if _, _, ok := server.get("memc.sess.key.abc"); ok {
+		t.Errorf("Session was not destroyed\n")
+	}
+	if err := store.Destroy("abc"); err != nil {
+		t.Errorf("Destroy of missing session should not fail: %v\n", err)
+	}
+}
+
+func TestLongLifetime(t *testing.T) {
+	server := newFakeServer(t)
+	store := NewStore(Options{Addr: server.addr(), Lifetime: 60 * 24 * time.Hour})
+	defer store.Close()
+
+	store.Write("abc", "a|b:1;")
+	if _, exptime, _ := server.get("memc.sess.key.abc"); exptime < time.Now().Unix() {
+		t.Errorf("Long expiration should be absolute: %d\n", exptime)
+	}
+	if err := store.Touch("abc"); err != nil {
+		t.Errorf("Can not touch session: %v\n", err)
+	}
+}
+
+func TestReadCompressed(t *testing.T) {
+	server := newFakeServer(t)
+	store := NewStore(Options{Addr: server.addr(), Prefix: "app."})
+	defer store.Close()
+
+	data := "user_id|i:42;"
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, uint32(len(data)))
+	w := zlib.NewWriter(&buf)
+	w.Write([]byte(data))
+	w.Close()
+	server.set("app.abc", item{value: buf.Bytes(), flags: FLAG_COMPRESSED | FLAG_COMPRESSION_ZLIB})
+	server.set("app.fastlz", item{value: buf.Bytes(), flags: FLAG_COMPRESSED})
+	server.set("app.long", item{value: []byte("42"), flags: 1})
+
+	if result, err := store.Read("abc"); err != nil || result != data {
+		t.Errorf("Compressed session was read incorrectly: %q, %v\n", result, err)
+	}
+	for _, id := range []string{"fastlz", "long"} {
+		if _, err := store.Read(id); err == nil {
+			t.Errorf("Session %q with unsupported flags should not be read\n", id)
+		}
+	}
+}
+
+func TestLock(t *testing.T) {
+	server := newFakeServer(t)
+	first := NewStore(Options{Addr: server.addr()})
+	second := NewStore(Options{Addr: server.addr(), LockRetries: 3, LockWaitMin: time.Millisecond, LockWaitMax: 2 * time.Millisecond})
+	defer first.Close()
+	defer second.Close()
+
+	if err := first.Lock("abc"); err != nil {
+		t.Fatalf("Can not lock session: %v\n", err)
+	}
+	if v, exptime, _ := server.get("lock.memc.sess.key.abc"); string(v.value) != "1" || exptime != 30 {
+		t.Errorf("Lock was taken incorrectly: %q, exptime %d\n", v.value, exptime)
+	}
+	if err := second.Lock("abc"); !errors.Is(err, ErrLockTimeout) {
+		t.Errorf("Locked session should not be locked again, have got: %v\n", err)
+	}
+
+	if err := first.Unlock("abc"); err != nil {
+		t.Errorf("Can not unlock session: %v\n", err)
+	}
+	if err := second.Lock("abc"); err != nil {
+		t.Errorf("Can not lock released session: %v\n", err)
+	}
+}
+
+func TestInvalidKey(t *testing.T) {
+	store := NewStore(Options{Addr: "127.0.0.1:1"})
+	for _, id := range []string{"a b", "a\nb", strings.Repeat("a", 250)} {
+		if _, err := store.Read(id); err == nil {
+			t.Errorf("Session id %q should be rejected\n", id)
+		}
+	}
+}
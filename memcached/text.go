@@ -0,0 +1,150 @@
+package memcached
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var errNotStored = errors.New("memcached: item was not stored")
+
+// item is the value of memcached key with its flags.
+type item struct {
+	value []byte
+	flags uint32
+}
+
+// conn is the minimal client of memcached text protocol.
+type conn struct {
+	c       net.Conn
+	r       *bufio.Reader
+	w       *bufio.Writer
+	timeout time.Duration
+}
+
+func dial(network, addr string, timeout time.Duration) (*conn, error) {
+	c, err := net.DialTimeout(network, addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{
+		c:       c,
+		r:       bufio.NewReader(c),
+		w:       bufio.NewWriter(c),
+		timeout: timeout,
+	}, nil
+}
+
+// get returns nil item if the key does not exist.
+func (c *conn) get(key string) (*item, error) {
+	if err := c.send("get " + key + "\r\n"); err != nil {
+		return nil, err
+	}
+
+	var res *item
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if line == "END" {
+			return res, nil
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[0] != "VALUE" {
+			return nil, Error(line)
+		}
+		flags, err := strconv.ParseUint(fields[2], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("memcached: invalid flags in %q", line)
+		}
+		size, err := strconv.Atoi(fields[3])
+		if err != nil || size < 0 {
+			return nil, fmt.Errorf("memcached: invalid size in %q", line)
+		}
+		buf := make([]byte, size+2)
+		if _, err = io.ReadFull(c.r, buf); err != nil {
+			return nil, err
+		}
+		res = &item{value: buf[:size], flags: uint32(flags)}
+	}
+}
+
+// store runs set or add command, errNotStored is returned when add finds existing key.
+func (c *conn) store(command, key string, value []byte, flags uint32, exptime int64) error {
+	header := fmt.Sprintf("%s %s %d %d %d\r\n", command, key, flags, exptime, len(value))
+	if err := c.send(header, string(value), "\r\n"); err != nil {
+		return err
+	}
+	line, err := c.readLine()
+	if err != nil {
+		return err
+	}
+	switch line {
+	case "STORED":
+		return nil
+	case "NOT_STORED", "EXISTS":
+		return errNotStored
+	}
+	return Error(line)
+}
+
+// delete does not fail if the key does not exist.
+func (c *conn) delete(key string) error {
+	return c.simple("delete "+key+"\r\n", "DELETED", "NOT_FOUND")
+}
+
+func (c *conn) touch(key string, exptime int64) error {
+	return c.simple(fmt.Sprintf("touch %s %d\r\n", key, exptime), "TOUCHED", "NOT_FOUND")
+}
+
+func (c *conn) simple(command string, expected ...string) error {
+	if err := c.send(command); err != nil {
+		return err
+	}
+	line, err := c.readLine()
+	if err != nil {
+		return err
+	}
+	for _, e := range expected {
+		if line == e {
+			return nil
+		}
+	}
+	return Error(line)
+}
+
+func (c *conn) send(parts ...string) error {
+	if c.timeout > 0 {
+		c.c.SetDeadline(time.Now().Add(c.timeout))
+	}
+	for _, part := range parts {
+		c.w.WriteString(part)
+	}
+	return c.w.Flush()
+}
+
+func (c *conn) readLine() (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(line, "\r\n"), nil
+}
+
+func (c *conn) close() error {
+	return c.c.Close()
+}
+
+// Error is the error reply of memcached server.
+type Error string
+
+func (e Error) Error() string {
+	return "memcached: " + string(e)
+}
@@ -0,0 +1,28 @@
+package php_session_decoder
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidName = errors.New("php_session: Invalid name of session variable")
+
+// validatePhpName checks that the name is read back the same way by the php handler.
+func validatePhpName(name string) error {
+	if strings.ContainsRune(name, SEPARATOR_VALUE_NAME) {
+		return fmt.Errorf("%w %q: it contains %q", ErrInvalidName, name, SEPARATOR_VALUE_NAME)
+	}
+	if strings.HasPrefix(name, string(MARKER_UNDEFINED)) {
+		return fmt.Errorf("%w %q: it starts with %q", ErrInvalidName, name, MARKER_UNDEFINED)
+	}
+	return nil
+}
+
+// validateBinaryName checks that the length of the name fits into a single byte without the undefined flag.
+func validateBinaryName(name string) error {
+	if len(name) > BINARY_MAX_NAME_LENGTH {
+		return fmt.Errorf("%w %q: it is longer than %d bytes", ErrInvalidName, name, BINARY_MAX_NAME_LENGTH)
+	}
+	return nil
+}
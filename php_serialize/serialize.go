@@ -1,8 +1,10 @@
 package php_serialize
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
+	"io"
 	"strconv"
 )
 
@@ -12,6 +14,14 @@ func Serialize(v PhpValue) (string, error) {
 	return encoder.Encode(v)
 }
 
+// Writer is the destination of serialized data, it is implemented by bytes.Buffer and bufio.Writer.
+type Writer interface {
+	io.Writer
+	io.ByteWriter
+	io.StringWriter
+	WriteRune(r rune) (int, error)
+}
+
 type Serializer struct {
 	lastErr    error
 	encodeFunc SerializedEncodeFunc
@@ -26,53 +36,138 @@ func (s *Serializer) SetSerializedEncodeFunc(f SerializedEncodeFunc) {
 }
 
 func (s *Serializer) Encode(v PhpValue) (string, error) {
-	var value bytes.Buffer
+	var buffer bytes.Buffer
+	s.lastErr = nil
+	s.encode(&buffer, v)
+	return buffer.String(), s.lastErr
+}
+
+// EncodeTo writes serialized value to w without building it in memory first.
+// The first write error stops writing and is returned.
+func (s *Serializer) EncodeTo(w io.Writer, v PhpValue) error {
+	s.lastErr = nil
+	writer, ok := w.(Writer)
+	var buffered *bufio.Writer
+	if !ok {
+		buffered = bufio.NewWriter(w)
+		writer = buffered
+	}
+
+	ew := NewErrorWriter(writer)
+	s.encode(ew, v)
+	if err := ew.Err(); err != nil {
+		// the output is truncated, it matters more than errors of values
+		s.lastErr = err
+	}
+	if buffered != nil {
+		if err := buffered.Flush(); err != nil {
+			s.saveError(err)
+		}
+	}
+	return s.lastErr
+}
+
+// AppendEncode appends serialized value to dst and returns the extended buffer.
+func (s *Serializer) AppendEncode(dst []byte, v PhpValue) ([]byte, error) {
+	buffer := bytes.NewBuffer(dst)
+	s.lastErr = nil
+	s.encode(buffer, v)
+	return buffer.Bytes(), s.lastErr
+}
+
+// ErrorWriter keeps the first error of w and skips the following writes,
+// so a sequence of writes can be checked once with Err.
+type ErrorWriter struct {
+	w   Writer
+	err error
+}
+
+func NewErrorWriter(w Writer) *ErrorWriter {
+	return &ErrorWriter{w: w}
+}
+
+// Err returns the first error of the underlying writer.
+func (ew *ErrorWriter) Err() error {
+	return ew.err
+}
+
+func (ew *ErrorWriter) Write(p []byte) (int, error) {
+	if ew.err != nil {
+		return 0, ew.err
+	}
+	n, err := ew.w.Write(p)
+	ew.err = err
+	return n, err
+}
+
+func (ew *ErrorWriter) WriteByte(c byte) error {
+	if ew.err != nil {
+		return ew.err
+	}
+	ew.err = ew.w.WriteByte(c)
+	return ew.err
+}
+
+func (ew *ErrorWriter) WriteString(str string) (int, error) {
+	if ew.err != nil {
+		return 0, ew.err
+	}
+	n, err := ew.w.WriteString(str)
+	ew.err = err
+	return n, err
+}
+
+func (ew *ErrorWriter) WriteRune(r rune) (int, error) {
+	if ew.err != nil {
+		return 0, ew.err
+	}
+	n, err := ew.w.WriteRune(r)
+	ew.err = err
+	return n, err
+}
 
+func (s *Serializer) encode(w Writer, v PhpValue) {
 	switch t := v.(type) {
 	default:
 		s.saveError(fmt.Errorf("php_serialize: Unknown type %T with value %#v", t, v))
 	case nil:
-		value = s.encodeNull()
+		s.encodeNull(w)
 	case bool:
-		value = s.encodeBool(v)
+		s.encodeBool(w, v)
 	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
-		value = s.encodeNumber(v)
+		s.encodeNumber(w, v)
 	case string:
-		value = s.encodeString(v, DELIMITER_STRING_LEFT, DELIMITER_STRING_RIGHT, true)
+		s.encodeString(w, v, DELIMITER_STRING_LEFT, DELIMITER_STRING_RIGHT, true)
 	case PhpArray, map[PhpValue]PhpValue, PhpSlice:
-		value = s.encodeArray(v, true)
+		s.encodeArray(w, v, true)
 	case *PhpObject:
-		value = s.encodeObject(v)
+		s.encodeObject(w, v)
 	case *PhpObjectSerialized:
-		value = s.encodeSerialized(v)
+		s.encodeSerialized(w, v)
 	case *PhpSplArray:
-		value = s.encodeSplArray(v)
+		s.encodeSplArray(w, v)
 	}
-
-	return value.String(), s.lastErr
 }
 
-func (s *Serializer) encodeNull() (buffer bytes.Buffer) {
-	buffer.WriteRune(TOKEN_NULL)
-	buffer.WriteRune(SEPARATOR_VALUES)
-	return
+func (s *Serializer) encodeNull(w Writer) {
+	w.WriteRune(TOKEN_NULL)
+	w.WriteRune(SEPARATOR_VALUES)
 }
 
-func (s *Serializer) encodeBool(v PhpValue) (buffer bytes.Buffer) {
-	buffer.WriteRune(TOKEN_BOOL)
-	buffer.WriteRune(SEPARATOR_VALUE_TYPE)
+func (s *Serializer) encodeBool(w Writer, v PhpValue) {
+	w.WriteRune(TOKEN_BOOL)
+	w.WriteRune(SEPARATOR_VALUE_TYPE)
 
 	if bVal, ok := v.(bool); ok && bVal {
-		buffer.WriteString("1")
+		w.WriteString("1")
 	} else {
-		buffer.WriteString("0")
+		w.WriteString("0")
 	}
 
-	buffer.WriteRune(SEPARATOR_VALUES)
-	return
+	w.WriteRune(SEPARATOR_VALUES)
 }
 
-func (s *Serializer) encodeNumber(v PhpValue) (buffer bytes.Buffer) {
+func (s *Serializer) encodeNumber(w Writer, v PhpValue) {
 	var val string
 
 	isFloat := false
@@ -110,107 +205,82 @@ func (s *Serializer) encodeNumber(v PhpValue) (buffer bytes.Buffer) {
 	}
 
 	if isFloat {
-		buffer.WriteRune(TOKEN_FLOAT)
+		w.WriteRune(TOKEN_FLOAT)
 	} else {
-		buffer.WriteRune(TOKEN_INT)
+		w.WriteRune(TOKEN_INT)
 	}
 
-	buffer.WriteRune(SEPARATOR_VALUE_TYPE)
-	buffer.WriteString(val)
-	buffer.WriteRune(SEPARATOR_VALUES)
-
-	return
+	w.WriteRune(SEPARATOR_VALUE_TYPE)
+	w.WriteString(val)
+	w.WriteRune(SEPARATOR_VALUES)
 }
 
-func (s *Serializer) encodeString(v PhpValue, left, right rune, isFinal bool) (buffer bytes.Buffer) {
+func (s *Serializer) encodeString(w Writer, v PhpValue, left, right rune, isFinal bool) {
 	val, _ := v.(string)
 
 	if isFinal {
-		buffer.WriteRune(TOKEN_STRING)
+		w.WriteRune(TOKEN_STRING)
 	}
 
-	buffer.WriteString(s.prepareLen(len(val)))
-	buffer.WriteRune(left)
-	buffer.WriteString(val)
-	buffer.WriteRune(right)
+	s.writeLen(w, len(val))
+	w.WriteRune(left)
+	w.WriteString(val)
+	w.WriteRune(right)
 
 	if isFinal {
-		buffer.WriteRune(SEPARATOR_VALUES)
+		w.WriteRune(SEPARATOR_VALUES)
 	}
-
-	return
 }
 
-func (s *Serializer) encodeArray(array PhpValue, isFinal bool) (buffer bytes.Buffer) {
-	var (
-		arrLen int
-		str    string
-	)
-
+func (s *Serializer) encodeArray(w Writer, array PhpValue, isFinal bool) {
 	if isFinal {
-		buffer.WriteRune(TOKEN_ARRAY)
+		w.WriteRune(TOKEN_ARRAY)
 	}
 
 	switch array := array.(type) {
 	case PhpArray:
-		arrLen = len(array)
-
-		buffer.WriteString(s.prepareLen(arrLen))
-		buffer.WriteRune(DELIMITER_OBJECT_LEFT)
+		s.writeLen(w, len(array))
+		w.WriteRune(DELIMITER_OBJECT_LEFT)
 
 		for k, v := range array {
-			str, _ = s.Encode(k)
-			buffer.WriteString(str)
-			str, _ = s.Encode(v)
-			buffer.WriteString(str)
+			s.encode(w, k)
+			s.encode(w, v)
 		}
 
 	case map[PhpValue]PhpValue:
-		arrLen = len(array)
-
-		buffer.WriteString(s.prepareLen(arrLen))
-		buffer.WriteRune(DELIMITER_OBJECT_LEFT)
+		s.writeLen(w, len(array))
+		w.WriteRune(DELIMITER_OBJECT_LEFT)
 
 		for k, v := range array {
-			str, _ = s.Encode(k)
-			buffer.WriteString(str)
-			str, _ = s.Encode(v)
-			buffer.WriteString(str)
+			s.encode(w, k)
+			s.encode(w, v)
 		}
 	case PhpSlice:
-		arrLen = len(array)
-
-		buffer.WriteString(s.prepareLen(arrLen))
-		buffer.WriteRune(DELIMITER_OBJECT_LEFT)
+		s.writeLen(w, len(array))
+		w.WriteRune(DELIMITER_OBJECT_LEFT)
 
 		for k, v := range array {
-			str, _ = s.Encode(k)
-			buffer.WriteString(str)
-			str, _ = s.Encode(v)
-			buffer.WriteString(str)
+			s.encode(w, k)
+			s.encode(w, v)
 		}
 	}
 
-	buffer.WriteRune(DELIMITER_OBJECT_RIGHT)
-
-	return
+	w.WriteRune(DELIMITER_OBJECT_RIGHT)
 }
 
-func (s *Serializer) encodeObject(v PhpValue) (buffer bytes.Buffer) {
+func (s *Serializer) encodeObject(w Writer, v PhpValue) {
 	obj, _ := v.(*PhpObject)
-	buffer.WriteRune(TOKEN_OBJECT)
-	buffer.WriteString(s.prepareClassName(obj.className))
-	encoded := s.encodeArray(obj.members, false)
-	buffer.WriteString(encoded.String())
-	return
+	w.WriteRune(TOKEN_OBJECT)
+	s.writeClassName(w, obj.className)
+	s.encodeArray(w, obj.members, false)
 }
 
-func (s *Serializer) encodeSerialized(v PhpValue) (buffer bytes.Buffer) {
+func (s *Serializer) encodeSerialized(w Writer, v PhpValue) {
 	var serialized string
 
 	obj, _ := v.(*PhpObjectSerialized)
-	buffer.WriteRune(TOKEN_OBJECT_SERIALIZED)
-	buffer.WriteString(s.prepareClassName(obj.className))
+	w.WriteRune(TOKEN_OBJECT_SERIALIZED)
+	s.writeClassName(w, obj.className)
 
 	if s.encodeFunc == nil {
 		serialized = obj.GetData()
@@ -221,41 +291,33 @@ func (s *Serializer) encodeSerialized(v PhpValue) (buffer bytes.Buffer) {
 		}
 	}
 
-	encoded := s.encodeString(serialized, DELIMITER_OBJECT_LEFT, DELIMITER_OBJECT_RIGHT, false)
-	buffer.WriteString(encoded.String())
-	return
+	s.encodeString(w, serialized, DELIMITER_OBJECT_LEFT, DELIMITER_OBJECT_RIGHT, false)
 }
 
-func (s *Serializer) encodeSplArray(v PhpValue) bytes.Buffer {
-	var buffer bytes.Buffer
+func (s *Serializer) encodeSplArray(w Writer, v PhpValue) {
 	obj, _ := v.(*PhpSplArray)
 
-	buffer.WriteRune(TOKEN_SPL_ARRAY)
-	buffer.WriteRune(SEPARATOR_VALUE_TYPE)
-
-	encoded := s.encodeNumber(obj.flags)
-	buffer.WriteString(encoded.String())
-
-	data, _ := s.Encode(obj.array)
-	buffer.WriteString(data)
+	w.WriteRune(TOKEN_SPL_ARRAY)
+	w.WriteRune(SEPARATOR_VALUE_TYPE)
 
-	buffer.WriteRune(SEPARATOR_VALUES)
-	buffer.WriteRune(TOKEN_SPL_ARRAY_MEMBERS)
-	buffer.WriteRune(SEPARATOR_VALUE_TYPE)
+	s.encodeNumber(w, obj.flags)
+	s.encode(w, obj.array)
 
-	data, _ = s.Encode(obj.properties)
-	buffer.WriteString(data)
+	w.WriteRune(SEPARATOR_VALUES)
+	w.WriteRune(TOKEN_SPL_ARRAY_MEMBERS)
+	w.WriteRune(SEPARATOR_VALUE_TYPE)
 
-	return buffer
+	s.encode(w, obj.properties)
 }
 
-func (s *Serializer) prepareLen(l int) string {
-	return string(SEPARATOR_VALUE_TYPE) + strconv.Itoa(l) + string(SEPARATOR_VALUE_TYPE)
+func (s *Serializer) writeLen(w Writer, l int) {
+	w.WriteRune(SEPARATOR_VALUE_TYPE)
+	w.WriteString(strconv.Itoa(l))
+	w.WriteRune(SEPARATOR_VALUE_TYPE)
 }
 
-func (s *Serializer) prepareClassName(name string) string {
-	encoded := s.encodeString(name, DELIMITER_STRING_LEFT, DELIMITER_STRING_RIGHT, false)
-	return encoded.String()
+func (s *Serializer) writeClassName(w Writer, name string) {
+	s.encodeString(w, name, DELIMITER_STRING_LEFT, DELIMITER_STRING_RIGHT, false)
 }
 
 func (s *Serializer) saveError(err error) {
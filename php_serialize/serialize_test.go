@@ -1,7 +1,10 @@
 package php_serialize
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"io"
 	"strings"
 	"testing"
 )
@@ -429,3 +432,76 @@ func TestEncodeSplArray(t *testing.T) {
 		t.Errorf("SplArray decoded incorrectly, expected: %q, got: %q\n", expected, data)
 	}
 }
+
+func TestEncodeTo(t *testing.T) {
+	var (
+		buffer bytes.Buffer
+		err    error
+	)
+
+	encoder := NewSerializer()
+	// io.MultiWriter hides WriteString and WriteRune of the buffer
+	if err = encoder.EncodeTo(io.MultiWriter(&buffer), PhpSlice{"foo", 5}); err != nil {
+		t.Errorf("Error while encoding value to writer: %v\n", err)
+	} else if buffer.String() != "a:2:{i:0;s:3:\"foo\";i:1;i:5;}" {
+		t.Errorf("Value encoded to writer incorrectly, have got: %q\n", buffer.String())
+	}
+}
+
+// failingWriter accepts limit bytes and fails after that.
+type failingWriter struct {
+	limit int
+}
+
+func (fw *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > fw.limit {
+		n := fw.limit
+		fw.limit = 0
+		return n, errors.New("write failed")
+	}
+	fw.limit -= len(p)
+	return len(p), nil
+}
+
+func (fw *failingWriter) WriteByte(c byte) error {
+	_, err := fw.Write([]byte{c})
+	return err
+}
+
+func (fw *failingWriter) WriteString(s string) (int, error) {
+	return fw.Write([]byte(s))
+}
+
+func (fw *failingWriter) WriteRune(r rune) (int, error) {
+	return fw.Write([]byte(string(r)))
+}
+
+func TestEncodeToErrors(t *testing.T) {
+	encoder := NewSerializer()
+	if err := encoder.EncodeTo(&failingWriter{limit: 5}, PhpSlice{"foo", 5}); err == nil || err.Error() != "write failed" {
+		t.Errorf("Error of Writer was not returned: %v\n", err)
+	}
+	if err := encoder.EncodeTo(&failingWriter{limit: 100}, PhpSlice{"foo", 5}); err != nil {
+		t.Errorf("Previous error should not be returned again: %v\n", err)
+	}
+
+	// io.MultiWriter is buffered, the error is returned by Flush
+	if err := encoder.EncodeTo(io.MultiWriter(&failingWriter{limit: 5}), "foo"); err == nil {
+		t.Errorf("Error of io.Writer was not returned\n")
+	}
+	if _, err := encoder.Encode(struct{}{}); err == nil {
+		t.Errorf("Unknown type should not be encoded\n")
+	}
+	if val, err := encoder.Encode("foo"); err != nil || val != `s:3:"foo";` {
+		t.Errorf("Previous error should not be returned again: %q %v\n", val, err)
+	}
+}
+
+func TestAppendEncode(t *testing.T) {
+	encoder := NewSerializer()
+	if val, err := encoder.AppendEncode([]byte("prefix|"), "foo"); err != nil {
+		t.Errorf("Error while appending encoded value: %v\n", err)
+	} else if string(val) != "prefix|s:3:\"foo\";" {
+		t.Errorf("Value appended incorrectly, have got: %q\n", val)
+	}
+}
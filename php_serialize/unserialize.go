@@ -1,9 +1,11 @@
 package php_serialize
 
 import (
+	"bufio"
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"strconv"
 	"strings"
@@ -22,9 +24,16 @@ func UnSerialize(s string) (PhpValue, error) {
 	return decoder.Decode()
 }
 
+// RuneReader is the source of serialized data. Readers which don't implement
+// io.RuneReader are wrapped into bufio.Reader.
+type RuneReader interface {
+	io.Reader
+	io.RuneReader
+}
+
 type UnSerializer struct {
 	source     string
-	r          *strings.Reader
+	r          RuneReader
 	decodeFunc SerializedDecodeFunc
 	curDepth   int
 	maxSize    int
@@ -59,10 +68,31 @@ func NewUnSerializerWithLimits(data string, maxSize int, maxDepth int) *UnSerial
 	return &res
 }
 
+// NewUnSerializerFromReader creates UnSerializer which decodes values directly from r.
+func NewUnSerializerFromReader(r io.Reader) *UnSerializer {
+	return NewUnSerializerFromReaderWithLimits(r, 0, 0)
+}
+
+// NewUnSerializerFromReaderWithLimits is the same as NewUnSerializerWithLimits but reads values from r.
+func NewUnSerializerFromReaderWithLimits(r io.Reader, maxSize int, maxDepth int) *UnSerializer {
+	res := NewUnSerializerWithLimits("", maxSize, maxDepth)
+	res.SetRuneReader(r)
+	return res
+}
+
 func (us *UnSerializer) SetReader(r *strings.Reader) {
 	us.r = r
 }
 
+// SetRuneReader makes UnSerializer read values from r, it is buffered unless it implements io.RuneReader.
+func (us *UnSerializer) SetRuneReader(r io.Reader) {
+	if rr, ok := r.(RuneReader); ok {
+		us.r = rr
+	} else {
+		us.r = bufio.NewReader(r)
+	}
+}
+
 func (us *UnSerializer) SetSerializedDecodeFunc(f SerializedDecodeFunc) {
 	us.decodeFunc = f
 }
@@ -186,7 +216,7 @@ func (us *UnSerializer) decodeString(left, right rune, isFinal bool) (PhpValue,
 			return nil, fmt.Errorf("php_serialize: Unserializable object length looks too big(%d). If you are sure you wanna unserialise it, please increase max size limit", val)
 		} else {
 			buf := make([]byte, strLen)
-			if readLen, err = us.r.Read(buf); err != nil {
+			if readLen, err = io.ReadFull(us.r, buf); err != nil {
 				return nil, fmt.Errorf("php_serialize: Error while reading string value: %v", err)
 			} else {
 				if readLen != strLen {
@@ -417,3 +447,117 @@ func (us *UnSerializer) decodeSplArray() (PhpValue, error) {
 
 	return val, nil
 }
+
+// Skip moves past the next value without building it, it is much cheaper than Decode
+// for values which are not needed.
+func (us *UnSerializer) Skip() error {
+	if us.r == nil {
+		us.r = strings.NewReader(us.source)
+	}
+
+	us.curDepth++
+	if us.curDepth > us.maxDepth {
+		return ErrDepthLimit
+	}
+
+	defer func() { us.curDepth-- }()
+
+	// unlike Decode, missing value is an error, otherwise truncated arrays would be skipped
+	token, _, err := us.r.ReadRune()
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return fmt.Errorf("php_serialize: Error while skipping value: %w", err)
+	}
+
+	switch token {
+	default:
+		return fmt.Errorf("php_serialize: Unknown token %#U", token)
+	case TOKEN_NULL:
+		return us.expect(SEPARATOR_VALUES)
+	case TOKEN_BOOL, TOKEN_INT, TOKEN_FLOAT, TOKEN_REFERENCE, TOKEN_REFERENCE_OBJECT:
+		if err = us.expect(SEPARATOR_VALUE_TYPE); err != nil {
+			return err
+		}
+		if _, err = us.readUntil(SEPARATOR_VALUES); err != nil {
+			return fmt.Errorf("php_serialize: Error while skipping value: %v", err)
+		}
+		return nil
+	case TOKEN_STRING:
+		return us.skipString(DELIMITER_STRING_LEFT, DELIMITER_STRING_RIGHT, true)
+	case TOKEN_ARRAY:
+		return us.skipArray()
+	case TOKEN_OBJECT:
+		if err = us.skipString(DELIMITER_STRING_LEFT, DELIMITER_STRING_RIGHT, false); err != nil {
+			return err
+		}
+		return us.skipArray()
+	case TOKEN_OBJECT_SERIALIZED:
+		if err = us.skipString(DELIMITER_STRING_LEFT, DELIMITER_STRING_RIGHT, false); err != nil {
+			return err
+		}
+		return us.skipString(DELIMITER_OBJECT_LEFT, DELIMITER_OBJECT_RIGHT, false)
+	case TOKEN_SPL_ARRAY:
+		return us.skipSplArray()
+	}
+}
+
+func (us *UnSerializer) skipString(left, right rune, isFinal bool) error {
+	strLen, err := us.readLen()
+	if err != nil {
+		return err
+	}
+	if err = us.expect(left); err != nil {
+		return err
+	}
+	if _, err = io.CopyN(io.Discard, us.r, int64(strLen)); err != nil {
+		return fmt.Errorf("php_serialize: Error while skipping string value: %v", err)
+	}
+	if err = us.expect(right); err != nil {
+		return err
+	}
+	if isFinal {
+		return us.expect(SEPARATOR_VALUES)
+	}
+	return nil
+}
+
+func (us *UnSerializer) skipArray() error {
+	arrLen, err := us.readLen()
+	if err != nil {
+		return err
+	}
+	if err = us.expect(DELIMITER_OBJECT_LEFT); err != nil {
+		return err
+	}
+	for i := 0; i < 2*arrLen; i++ {
+		if err = us.Skip(); err != nil {
+			return err
+		}
+	}
+	return us.expect(DELIMITER_OBJECT_RIGHT)
+}
+
+func (us *UnSerializer) skipSplArray() error {
+	for _, expected := range []rune{SEPARATOR_VALUE_TYPE, TOKEN_INT, SEPARATOR_VALUE_TYPE} {
+		if err := us.expect(expected); err != nil {
+			return err
+		}
+	}
+	if _, err := us.readUntil(SEPARATOR_VALUES); err != nil {
+		return fmt.Errorf("php_serialize: Unable to read flags of SplArray: %v", err)
+	}
+	if err := us.Skip(); err != nil {
+		return fmt.Errorf("php_serialize: Can't skip SplArray: %v", err)
+	}
+	for _, expected := range []rune{SEPARATOR_VALUES, TOKEN_SPL_ARRAY_MEMBERS, SEPARATOR_VALUE_TYPE} {
+		if err := us.expect(expected); err != nil {
+			return err
+		}
+	}
+	if err := us.Skip(); err != nil {
+		return fmt.Errorf("php_serialize: Can't skip properties of SplArray: %v", err)
+	}
+	return nil
+}
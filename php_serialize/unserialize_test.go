@@ -3,8 +3,11 @@ package php_serialize
 import (
 	"encoding/json"
 	"errors"
+	"io"
 	"strings"
 	"testing"
+	"testing/iotest"
+	"time"
 )
 
 func TestDecodeNil(t *testing.T) {
@@ -554,3 +557,59 @@ func FuzzUnserializer(f *testing.F) {
 		}
 	})
 }
+
+func TestDecodeFromReader(t *testing.T) {
+	source := "a:2:{i:0;s:8:\"тест\";s:3:\"obj\";O:4:\"Test\":1:{s:1:\"a\";d:1.5;}}"
+	decoder := NewUnSerializerFromReader(iotest.OneByteReader(strings.NewReader(source)))
+	if val, err := decoder.Decode(); err != nil {
+		t.Errorf("Error while decoding value from reader: %v\n", err)
+	} else if arrVal, ok := val.(PhpArray); !ok {
+		t.Errorf("Unable to convert %v to PhpArray\n", val)
+	} else if arrVal[0] != "тест" {
+		t.Errorf("String value decoded incorrectly, have got: %v\n", arrVal[0])
+	} else if obj, ok := arrVal["obj"].(*PhpObject); !ok {
+		t.Errorf("Unable to convert %v to PhpObject\n", arrVal["obj"])
+	} else if a, _ := obj.GetPublic("a"); a != 1.5 {
+		t.Errorf("Object member decoded incorrectly, have got: %v\n", a)
+	}
+}
+
+func TestSkip(t *testing.T) {
+	skipped := []string{
+		"N;",
+		"b:1;",
+		"i:-5;",
+		"d:0.5;",
+		"s:8:\"тест\";",
+		"a:2:{i:0;s:1:\"}\";s:1:\"a\";a:1:{i:0;R:2;}}",
+		"O:4:\"Test\":1:{s:9:\"\x00Test\x00foo\";b:0;}",
+		"C:11:\"ArrayObject\":21:{x:i:0;a:0:{};m:a:0:{}}",
+		"x:i:0;a:1:{s:3:\"foo\";s:3:\"bar\";};m:a:0:{}",
+	}
+
+	decoder := NewUnSerializer(strings.Join(skipped, "") + "s:4:\"last\";")
+	for _, v := range skipped {
+		if err := decoder.Skip(); err != nil {
+			t.Errorf("Error while skipping %q: %v\n", v, err)
+		}
+	}
+	if val, err := decoder.Decode(); err != nil || val != "last" {
+		t.Errorf("Value after skipped ones decoded incorrectly: %v, %v\n", val, err)
+	}
+}
+
+func TestSkipInvalid(t *testing.T) {
+	for _, v := range []string{"s:5:\"foo\";", "a:2:{i:0;i:1;}", "q:1;", "", "a:9000000:{", "a:2:{i:0;i:1;i:1;"} {
+		if err := NewUnSerializer(v).Skip(); err == nil {
+			t.Errorf("Invalid value %q should not be skipped\n", v)
+		}
+	}
+
+	start := time.Now()
+	if err := NewUnSerializer("a:9000000:{").Skip(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Truncated array should be reported as unexpected EOF: %v\n", err)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("Truncated array should be rejected right away, it took %v\n", elapsed)
+	}
+}
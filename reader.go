@@ -0,0 +1,68 @@
+package php_session_decoder
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"unicode/utf8"
+)
+
+// sessionReader buffers session data and keeps a copy of consumed bytes while
+// recording, so decoders get the original serialized value of every variable.
+type sessionReader struct {
+	r         *bufio.Reader
+	recording bool
+	record    bytes.Buffer
+}
+
+func newSessionReader(r io.Reader) *sessionReader {
+	return &sessionReader{
+		r: bufio.NewReader(r),
+	}
+}
+
+func (sr *sessionReader) Read(p []byte) (n int, err error) {
+	n, err = sr.r.Read(p)
+	if sr.recording {
+		sr.record.Write(p[:n])
+	}
+	return
+}
+
+func (sr *sessionReader) ReadByte() (b byte, err error) {
+	if b, err = sr.r.ReadByte(); err == nil && sr.recording {
+		sr.record.WriteByte(b)
+	}
+	return
+}
+
+func (sr *sessionReader) ReadRune() (r rune, size int, err error) {
+	if r, size, err = sr.r.ReadRune(); err != nil || !sr.recording {
+		return
+	}
+	if r == utf8.RuneError && size == 1 {
+		// keep the invalid byte as is instead of the replacement char
+		sr.r.UnreadRune()
+		b, _ := sr.r.ReadByte()
+		sr.record.WriteByte(b)
+	} else {
+		sr.record.WriteRune(r)
+	}
+	return
+}
+
+// atEOF reports whether all data was consumed.
+func (sr *sessionReader) atEOF() bool {
+	_, err := sr.r.Peek(1)
+	return err != nil
+}
+
+func (sr *sessionReader) startRecord() {
+	sr.record.Reset()
+	sr.recording = true
+}
+
+func (sr *sessionReader) stopRecord() string {
+	sr.recording = false
+	return sr.record.String()
+}
@@ -0,0 +1,2 @@
+// Package redis provides session store compatible with phpredis session handler.
+package redis
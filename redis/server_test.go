@@ -0,0 +1,176 @@
+package redis
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeServer is in-process stand-in of redis server which supports commands used by Store.
+type fakeServer struct {
+	listener net.Listener
+
+	mu       sync.Mutex
+	data     map[string]string
+	expires  map[string]time.Time
+	commands []string
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &fakeServer{
+		listener: listener,
+		data:     make(map[string]string),
+		expires:  make(map[string]time.Time),
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			c, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(c)
+		}
+	}()
+	return server
+}
+
+func (fs *fakeServer) addr() string {
+	return fs.listener.Addr().String()
+}
+
+func (fs *fakeServer) get(key string) (string, bool) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if expire, ok := fs.expires[key]; ok && time.Now().After(expire) {
+		delete(fs.data, key)
+		delete(fs.expires, key)
+	}
+	v, ok := fs.data[key]
+	return v, ok
+}
+
+func (fs *fakeServer) ttl(key string) time.Duration {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return time.Until(fs.expires[key])
+}
+
+func (fs *fakeServer) set(key, value string, ttl time.Duration) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	fs.data[key] = value
+	delete(fs.expires, key)
+	if ttl > 0 {
+		fs.expires[key] = time.Now().Add(ttl)
+	}
+}
+
+func (fs *fakeServer) del(key string) int {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if _, ok := fs.data[key]; !ok {
+		return 0
+	}
+	delete(fs.data, key)
+	delete(fs.expires, key)
+	return 1
+}
+
+func (fs *fakeServer) serve(c net.Conn) {
+	defer c.Close()
+	r := bufio.NewReader(c)
+
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		fs.mu.Lock()
+		fs.commands = append(fs.commands, strings.ToUpper(args[0]))
+		fs.mu.Unlock()
+		io.WriteString(c, fs.execute(args))
+	}
+}
+
+func (fs *fakeServer) execute(args []string) string {
+	switch strings.ToUpper(args[0]) {
+	case "AUTH", "SELECT":
+		return "+OK\r\n"
+	case "GET":
+		if v, ok := fs.get(args[1]); ok {
+			return fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+		}
+		return "$-1\r\n"
+	case "SETEX":
+		seconds, _ := strconv.Atoi(args[2])
+		fs.set(args[1], args[3], time.Duration(seconds)*time.Second)
+		return "+OK\r\n"
+	case "SET":
+		// only SET key value NX PX ms is supported
+		if _, ok := fs.get(args[1]); ok {
+			return "$-1\r\n"
+		}
+		ms, _ := strconv.Atoi(args[5])
+		fs.set(args[1], args[2], time.Duration(ms)*time.Millisecond)
+		return "+OK\r\n"
+	case "EXPIRE":
+		v, ok := fs.get(args[1])
+		if !ok {
+			return ":0\r\n"
+		}
+		seconds, _ := strconv.Atoi(args[2])
+		fs.set(args[1], v, time.Duration(seconds)*time.Second)
+		return ":1\r\n"
+	case "DEL":
+		return fmt.Sprintf(":%d\r\n", fs.del(args[1]))
+	case "EVAL":
+		if args[1] != unlockScript {
+			return "-ERR unknown script\r\n"
+		}
+		if v, _ := fs.get(args[3]); v == args[4] {
+			return fmt.Sprintf(":%d\r\n", fs.del(args[3]))
+		}
+		return ":0\r\n"
+	}
+	return "-ERR unknown command '" + args[0] + "'\r\n"
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, count)
+	for i := range args {
+		if line, err = r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		buf := make([]byte, size+2)
+		if _, err = io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
@@ -0,0 +1,242 @@
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/solidwall/php_session_decoder"
+)
+
+const (
+	KEY_PREFIX_DEFAULT = "PHPREDIS_SESSION:"
+	LOCK_SUFFIX        = "_LOCK"
+
+	// the same script phpredis uses to release only its own lock
+	unlockScript = "if redis.call(\"get\",KEYS[1]) == ARGV[1] then return redis.call(\"del\",KEYS[1]) else return 0 end"
+)
+
+var (
+	ErrLockTimeout = fmt.Errorf("redis: %w", php_session_decoder.ErrLockTimeout)
+	ErrLockLost    = errors.New("redis: Session lock was lost")
+)
+
+// Options mirror php.ini settings of phpredis session handler, zero values are replaced with PHP defaults.
+type Options struct {
+	Network  string
+	Addr     string
+	Password string
+	Database int
+	Timeout  time.Duration
+	// Prefix is the prefix of session keys, the `prefix` parameter of save path.
+	Prefix string
+	// Lifetime is session.gc_maxlifetime, TTL of session keys.
+	Lifetime time.Duration
+	// LockExpire is redis.session.lock_expire, TTL of lock keys.
+	LockExpire time.Duration
+	// LockWaitTime is redis.session.lock_wait_time, the delay between attempts to take a lock.
+	LockWaitTime time.Duration
+	// LockRetries is redis.session.lock_retries, negative value means to wait forever.
+	LockRetries int
+	// LockSecret is stored in lock keys, phpredis uses `hostname|pid`.
+	LockSecret string
+}
+
+// Store reads and writes sessions the same way phpredis does: data is stored in
+// `<prefix><id>` keys with TTL and locks in `<prefix><id>_LOCK` keys.
+type Store struct {
+	options Options
+
+	mu     sync.Mutex
+	conn   *conn
+	locked map[string]bool
+}
+
+var (
+	_ php_session_decoder.Store   = (*Store)(nil)
+	_ php_session_decoder.Locker  = (*Store)(nil)
+	_ php_session_decoder.Toucher = (*Store)(nil)
+)
+
+func NewStore(options Options) *Store {
+	if options.Network == "" {
+		options.Network = "tcp"
+	}
+	if options.Addr == "" {
+		options.Addr = "127.0.0.1:6379"
+	}
+	if options.Prefix == "" {
+		options.Prefix = KEY_PREFIX_DEFAULT
+	}
+	if options.Lifetime == 0 {
+		options.Lifetime = 1440 * time.Second
+	}
+	if options.LockExpire == 0 {
+		options.LockExpire = 30 * time.Second
+	}
+	if options.LockWaitTime == 0 {
+		options.LockWaitTime = 20 * time.Millisecond
+	}
+	if options.LockRetries == 0 {
+		options.LockRetries = 100
+	}
+	if options.LockSecret == "" {
+		hostname, _ := os.Hostname()
+		options.LockSecret = hostname + "|" + strconv.Itoa(os.Getpid())
+	}
+
+	return &Store{
+		options: options,
+		locked:  make(map[string]bool),
+	}
+}
+
+// Key returns redis key of the session.
+func (s *Store) Key(id string) string {
+	return s.options.Prefix + id
+}
+
+func (s *Store) Read(id string) (string, error) {
+	reply, err := s.do("GET", s.Key(id))
+	if err != nil {
+		return "", err
+	}
+	if reply == nil {
+		return "", php_session_decoder.ErrNotFound
+	}
+	data, _ := reply.(string)
+	return data, nil
+}
+
+// Write stores the session with Lifetime TTL. If the session is locked by this store,
+// it is written only while the lock is still held, like phpredis does.
+func (s *Store) Write(id string, data string) error {
+	if err := s.checkLock(id); err != nil {
+		return err
+	}
+	_, err := s.do("SETEX", s.Key(id), s.seconds(s.options.Lifetime), data)
+	return err
+}
+
+// Touch updates TTL of the session without writing it, like phpredis does for unchanged sessions.
+func (s *Store) Touch(id string) error {
+	if err := s.checkLock(id); err != nil {
+		return err
+	}
+	_, err := s.do("EXPIRE", s.Key(id), s.seconds(s.options.Lifetime))
+	return err
+}
+
+func (s *Store) Destroy(id string) error {
+	if err := s.checkLock(id); err != nil {
+		return err
+	}
+	_, err := s.do("DEL", s.Key(id))
+	return err
+}
+
+// Lock takes session lock, retrying LockRetries times with LockWaitTime delay.
+func (s *Store) Lock(id string) error {
+	key := s.Key(id) + LOCK_SUFFIX
+	for i := 0; s.options.LockRetries < 0 || i < s.options.LockRetries; i++ {
+		reply, err := s.do("SET", key, s.options.LockSecret, "NX", "PX", strconv.FormatInt(s.options.LockExpire.Milliseconds(), 10))
+		if err != nil {
+			return err
+		}
+		if reply != nil {
+			s.mu.Lock()
+			s.locked[id] = true
+			s.mu.Unlock()
+			return nil
+		}
+		time.Sleep(s.options.LockWaitTime)
+	}
+	return ErrLockTimeout
+}
+
+// Unlock releases session lock if it is still held by this store.
+func (s *Store) Unlock(id string) error {
+	s.mu.Lock()
+	delete(s.locked, id)
+	s.mu.Unlock()
+
+	_, err := s.do("EVAL", unlockScript, "1", s.Key(id)+LOCK_SUFFIX, s.options.LockSecret)
+	return err
+}
+
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.close()
+	s.conn = nil
+	return err
+}
+
+func (s *Store) checkLock(id string) error {
+	s.mu.Lock()
+	locked := s.locked[id]
+	s.mu.Unlock()
+	if !locked {
+		return nil
+	}
+
+	reply, err := s.do("GET", s.Key(id)+LOCK_SUFFIX)
+	if err != nil {
+		return err
+	}
+	if secret, _ := reply.(string); secret != s.options.LockSecret {
+		return ErrLockLost
+	}
+	return nil
+}
+
+func (s *Store) seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(d/time.Second), 10)
+}
+
+// do runs the command over the shared connection, which is reopened after network errors.
+func (s *Store) do(args ...string) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return nil, err
+		}
+	}
+
+	reply, err := s.conn.do(args...)
+	if _, ok := err.(Error); err != nil && !ok {
+		s.conn.close()
+		s.conn = nil
+	}
+	return reply, err
+}
+
+func (s *Store) connect() error {
+	c, err := dial(s.options.Network, s.options.Addr, s.options.Timeout)
+	if err != nil {
+		return err
+	}
+	if s.options.Password != "" {
+		if _, err = c.do("AUTH", s.options.Password); err != nil {
+			c.close()
+			return fmt.Errorf("redis: unable to authenticate: %v", err)
+		}
+	}
+	if s.options.Database != 0 {
+		if _, err = c.do("SELECT", strconv.Itoa(s.options.Database)); err != nil {
+			c.close()
+			return fmt.Errorf("redis: unable to select database: %v", err)
+		}
+	}
+	s.conn = c
+	return nil
+}
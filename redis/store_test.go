@@ -0,0 +1,133 @@
+package redis
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/solidwall/php_session_decoder"
+)
+
+func TestReadWrite(t *testing.T) {
+	server := newFakeServer(t)
+	store := NewStore(Options{Addr: server.addr(), Password: "secret", Database: 2})
+	defer store.Close()
+
+	if _, err := store.Read("abc"); !errors.Is(err, php_session_decoder.ErrNotFound) {
+		t.Errorf("Missing session should not be found, have got: %v\n", err)
+	}
+
+	session := php_session_decoder.NewPhpSession().Set("user_id", 42)
+	if err := php_session_decoder.Save(store, "abc", session, php_session_decoder.FORMAT_PHP); err != nil {
+		t.Errorf("Can not save session: %v\n", err)
+	}
+	server.mu.Lock()
+	if server.commands[0] != "AUTH" || server.commands[1] != "SELECT" {
+		t.Errorf("Connection was not prepared: %v\n", server.commands)
+	}
+	server.mu.Unlock()
+	if data, _ := server.get("PHPREDIS_SESSION:abc"); data != "user_id|i:42;" {
+		t.Errorf("Session was written incorrectly: %q\n", data)
+	}
+	if ttl := server.ttl("PHPREDIS_SESSION:abc"); ttl <= 1430*time.Second || ttl > 1440*time.Second {
+		t.Errorf("TTL of session is incorrect: %v\n", ttl)
+	}
+
+	if result, err := php_session_decoder.Load(store, "abc", php_session_decoder.FORMAT_PHP); err != nil {
+		t.Errorf("Can not load session: %v\n", err)
+	} else if v, _ := result.Get("user_id"); v != 42 {
+		t.Errorf("Session was loaded incorrectly: %#v\n", result)
+	}
+
+	if err := store.Destroy("abc"); err != nil {
+		t.Errorf("Can not destroy session: %v\n", err)
+	}
+	if _, ok := server.get("PHPREDIS_SESSION:abc"); ok {
+		t.Errorf("Session was not destroyed\n")
+	}
+}
+
+func TestTouch(t *testing.T) {
+	server := newFakeServer(t)
+	store := NewStore(Options{Addr: server.addr(), Prefix: "app:", Lifetime: time.Hour})
+	defer store.Close()
+
+	server.set("app:abc", "a|b:1;", time.Minute)
+	if err := store.Touch("abc"); err != nil {
+		t.Errorf("Can not touch session: %v\n", err)
+	}
+	if ttl := server.ttl("app:abc"); ttl <= 59*time.Minute {
+		t.Errorf("TTL of session was not updated: %v\n", ttl)
+	}
+}
+
+func TestLock(t *testing.T) {
+	server := newFakeServer(t)
+	first := NewStore(Options{Addr: server.addr(), LockSecret: "first", LockExpire: time.Second})
+	second := NewStore(Options{Addr: server.addr(), LockSecret: "second", LockRetries: 2, LockWaitTime: time.Millisecond})
+	defer first.Close()
+	defer second.Close()
+
+	if err := first.Lock("abc"); err != nil {
+		t.Fatalf("Can not lock session: %v\n", err)
+	}
+	if secret, _ := server.get("PHPREDIS_SESSION:abc_LOCK"); secret != "first" {
+		t.Errorf("Lock was taken incorrectly: %q\n", secret)
+	}
+	if err := second.Lock("abc"); !errors.Is(err, ErrLockTimeout) {
+		t.Errorf("Locked session should not be locked again, have got: %v\n", err)
+	}
+
+	// unlock by another secret must not release the lock
+	second.Unlock("abc")
+	if _, ok := server.get("PHPREDIS_SESSION:abc_LOCK"); !ok {
+		t.Errorf("Lock was released by another owner\n")
+	}
+
+	if err := first.Write("abc", "a|b:1;"); err != nil {
+		t.Errorf("Can not write locked session: %v\n", err)
+	}
+	if err := first.Unlock("abc"); err != nil {
+		t.Errorf("Can not unlock session: %v\n", err)
+	}
+	if err := second.Lock("abc"); err != nil {
+		t.Errorf("Can not lock released session: %v\n", err)
+	}
+}
+
+func TestLockLost(t *testing.T) {
+	server := newFakeServer(t)
+	store := NewStore(Options{Addr: server.addr(), LockSecret: "mine"})
+	defer store.Close()
+
+	if err := store.Lock("abc"); err != nil {
+		t.Fatalf("Can not lock session: %v\n", err)
+	}
+	server.set("PHPREDIS_SESSION:abc_LOCK", "other", time.Minute)
+
+	if err := store.Write("abc", "a|b:1;"); !errors.Is(err, ErrLockLost) {
+		t.Errorf("Session should not be written without lock, have got: %v\n", err)
+	}
+	if _, ok := server.get("PHPREDIS_SESSION:abc"); ok {
+		t.Errorf("Session was written without lock\n")
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	server := newFakeServer(t)
+	store := NewStore(Options{Addr: server.addr()})
+	defer store.Close()
+
+	server.set("PHPREDIS_SESSION:abc", "a|b:1;", 0)
+	if _, err := store.Read("abc"); err != nil {
+		t.Fatalf("Can not read session: %v\n", err)
+	}
+	store.conn.close()
+
+	if _, err := store.Read("abc"); err == nil {
+		t.Errorf("Error of closed connection should be returned\n")
+	}
+	if data, err := store.Read("abc"); err != nil || data != "a|b:1;" {
+		t.Errorf("Store has not reconnected: %q, %v\n", data, err)
+	}
+}
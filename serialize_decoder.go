@@ -0,0 +1,133 @@
+package php_session_decoder
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/solidwall/php_session_decoder/php_serialize"
+)
+
+func DecodeSerialize(phpSession string) (*PhpSession, error) {
+	decoder := NewPhpSerializeDecoder(phpSession)
+	return decoder.Decode()
+}
+
+// PhpSerializeDecoder decodes session data written with session.serialize_handler=php_serialize,
+// where the whole session is stored as a single serialized array.
+type PhpSerializeDecoder struct {
+	source     *sessionReader
+	decoder    *php_serialize.UnSerializer
+	decodeFunc php_serialize.SerializedDecodeFunc
+	// strict makes Decode fail on trailing data after the array, PHP ignores it
+	strict bool
+}
+
+func NewPhpSerializeDecoder(phpSession string) *PhpSerializeDecoder {
+	return NewPhpSerializeDecoderFromReader(strings.NewReader(phpSession))
+}
+
+// NewPhpSerializeDecoderFromReader creates decoder which reads session data directly from r.
+func NewPhpSerializeDecoderFromReader(r io.Reader) *PhpSerializeDecoder {
+	decoder := &PhpSerializeDecoder{
+		source:  newSessionReader(r),
+		decoder: php_serialize.NewUnSerializer(""),
+	}
+	decoder.decoder.SetRuneReader(decoder.source)
+	return decoder
+}
+
+func (pd *PhpSerializeDecoder) SetSerializedDecodeFunc(f php_serialize.SerializedDecodeFunc) {
+	pd.decodeFunc = f
+	pd.decoder.SetSerializedDecodeFunc(f)
+}
+
+func (pd *PhpSerializeDecoder) Decode() (*PhpSession, error) {
+	return pd.decode(nil)
+}
+
+// DecodeKeys decodes only given variables, values of others are skipped
+// and decoding stops as soon as all of them are found.
+func (pd *PhpSerializeDecoder) DecodeKeys(names ...string) (*PhpSession, error) {
+	return pd.decode(newNameFilter(names))
+}
+
+func (pd *PhpSerializeDecoder) decode(filter nameFilter) (*PhpSession, error) {
+	res := NewPhpSession()
+	res.decodeFunc = pd.decodeFunc
+	if pd.source.atEOF() {
+		return res, nil
+	}
+
+	// the array is read element by element to keep the order and raw values of variables
+	length, err := pd.readArrayLen()
+	if err != nil {
+		return res, err
+	}
+	for i := 0; i < length; i++ {
+		key, err := pd.decoder.Decode()
+		if err != nil {
+			return res, err
+		}
+		name, ok := key.(string)
+		if !ok {
+			return res, fmt.Errorf("php_session: unexpected name %#v of type %T", key, key)
+		}
+
+		if !filter.match(name) {
+			if err = pd.decoder.Skip(); err != nil {
+				return res, err
+			}
+			continue
+		}
+
+		pd.source.startRecord()
+		value, err := pd.decoder.Decode()
+		if err != nil {
+			return res, err
+		}
+		res.setDecoded(name, value, pd.source.stopRecord())
+		if filter.done(res) {
+			return res, nil
+		}
+	}
+
+	if token, err := pd.source.ReadByte(); err != nil || token != byte(php_serialize.DELIMITER_OBJECT_RIGHT) {
+		return res, fmt.Errorf("php_session: serialized array of %d elements is not closed", length)
+	}
+	if pd.strict && !pd.source.atEOF() {
+		return res, fmt.Errorf("php_session: unexpected data after serialized array")
+	}
+	return res, nil
+}
+
+// readArrayLen reads the `a:N:{` header of the serialized array.
+func (pd *PhpSerializeDecoder) readArrayLen() (int, error) {
+	for _, expected := range []rune{php_serialize.TOKEN_ARRAY, php_serialize.SEPARATOR_VALUE_TYPE} {
+		if token, err := pd.source.ReadByte(); err != nil || token != byte(expected) {
+			return 0, fmt.Errorf("php_session: expected serialized array")
+		}
+	}
+
+	buf := bytes.NewBuffer([]byte{})
+	for {
+		token, err := pd.source.ReadByte()
+		if err != nil {
+			return 0, fmt.Errorf("php_session: error while reading length of serialized array: %v", err)
+		} else if token == byte(php_serialize.SEPARATOR_VALUE_TYPE) {
+			break
+		}
+		buf.WriteByte(token)
+	}
+
+	length, err := strconv.Atoi(buf.String())
+	if err != nil || length < 0 {
+		return 0, fmt.Errorf("php_session: invalid length of serialized array %q", buf.String())
+	}
+	if token, err := pd.source.ReadByte(); err != nil || token != byte(php_serialize.DELIMITER_OBJECT_LEFT) {
+		return 0, fmt.Errorf("php_session: expected serialized array")
+	}
+	return length, nil
+}
@@ -0,0 +1,65 @@
+package php_session_decoder
+
+import (
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/solidwall/php_session_decoder/php_serialize"
+)
+
+func TestSerializeDecodeValues(t *testing.T) {
+	decoder := NewPhpSerializeDecoder("a:3:{s:8:\"login_ok\";b:1;s:7:\"inteiro\";i:34;s:3:\"arr\";a:1:{i:0;s:4:\"test\";}}")
+	if result, err := decoder.Decode(); err != nil {
+		t.Errorf("Can not decode serialized session %#v \n", err)
+	} else {
+		if v, ok := result.Get("login_ok"); !ok || v != true {
+			t.Errorf("Boolean value was decoded incorrectly: %#v\n", v)
+		}
+		if v, ok := result.Get("inteiro"); !ok || v != 34 {
+			t.Errorf("Int value was decoded incorrectly: %#v\n", v)
+		}
+		if v, ok := result.Get("arr"); !ok {
+			t.Errorf("Array value was not decoded \n")
+		} else if arrValue, ok := v.(php_serialize.PhpArray); !ok || arrValue[0] != "test" {
+			t.Errorf("Array value was decoded incorrectly: %#v\n", v)
+		}
+	}
+}
+
+func TestSerializeDecodeEmpty(t *testing.T) {
+	for _, data := range []string{"", "a:0:{}"} {
+		if result, err := DecodeSerialize(data); err != nil {
+			t.Errorf("Can not decode serialized session %q: %#v \n", data, err)
+		} else if result.Len() != 0 {
+			t.Errorf("Session %q was decoded incorrectly: %#v\n", data, result)
+		}
+	}
+}
+
+func TestSerializeDecodeInvalid(t *testing.T) {
+	for _, data := range []string{"s:4:\"test\";", "a:1:{i:0;b:1;}", "login_ok|b:1;"} {
+		if _, err := DecodeSerialize(data); err == nil {
+			t.Errorf("Session %q should not be decoded\n", data)
+		}
+	}
+}
+
+func TestSerializeDecodeFromReader(t *testing.T) {
+	source := strings.NewReader("a:2:{s:7:\"inteiro\";i:34;s:4:\"name\";s:8:\"тест\";}")
+	decoder := NewPhpSerializeDecoderFromReader(iotest.OneByteReader(source))
+	if result, err := decoder.Decode(); err != nil {
+		t.Errorf("Can not decode serialized session from reader %#v \n", err)
+	} else if v, ok := result.Get("name"); !ok || v != "тест" || result.Len() != 2 {
+		t.Errorf("Serialized session was decoded incorrectly: %#v\n", result)
+	}
+}
+
+func TestSerializeDecodeKeys(t *testing.T) {
+	decoder := NewPhpSerializeDecoder("a:3:{s:4:\"skip\";a:1:{i:0;s:1:\"x\";}s:7:\"user_id\";i:42;s:6:\"broken\";")
+	if result, err := decoder.DecodeKeys("user_id"); err != nil {
+		t.Errorf("Can not decode selected keys %#v \n", err)
+	} else if v, ok := result.Get("user_id"); !ok || v != 42 || result.Len() != 1 {
+		t.Errorf("Selected keys were decoded incorrectly: %#v\n", result)
+	}
+}
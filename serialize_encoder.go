@@ -0,0 +1,81 @@
+package php_session_decoder
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/solidwall/php_session_decoder/php_serialize"
+)
+
+func EncodeSerialize(data *PhpSession) (string, error) {
+	encoder := NewPhpSerializeEncoder(data)
+	return encoder.Encode()
+}
+
+// PhpSerializeEncoder encodes session data for session.serialize_handler=php_serialize.
+type PhpSerializeEncoder struct {
+	data    *PhpSession
+	encoder *php_serialize.Serializer
+}
+
+func NewPhpSerializeEncoder(data *PhpSession) *PhpSerializeEncoder {
+	return &PhpSerializeEncoder{
+		data:    data,
+		encoder: php_serialize.NewSerializer(),
+	}
+}
+
+func (pe *PhpSerializeEncoder) SetSerializedEncodeFunc(f php_serialize.SerializedEncodeFunc) {
+	pe.encoder.SetSerializedEncodeFunc(f)
+}
+
+func (pe *PhpSerializeEncoder) Encode() (string, error) {
+	buf := bytes.NewBuffer([]byte{})
+	err := pe.encode(buf)
+	return buf.String(), err
+}
+
+// EncodeTo writes encoded session to w without building it in memory first.
+func (pe *PhpSerializeEncoder) EncodeTo(w io.Writer) error {
+	return encodeTo(w, pe.encode)
+}
+
+// AppendEncode appends encoded session to dst and returns the extended buffer.
+func (pe *PhpSerializeEncoder) AppendEncode(dst []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(dst)
+	err := pe.encode(buf)
+	return buf.Bytes(), err
+}
+
+func (pe *PhpSerializeEncoder) encode(buf php_serialize.Writer) error {
+	if pe.data == nil {
+		return nil
+	}
+	var err error
+
+	// the array is written element by element to keep the order and raw values of variables
+	buf.WriteRune(php_serialize.TOKEN_ARRAY)
+	buf.WriteRune(php_serialize.SEPARATOR_VALUE_TYPE)
+	buf.WriteString(strconv.Itoa(pe.data.Len() - pe.data.countUndefined()))
+	buf.WriteRune(php_serialize.SEPARATOR_VALUE_TYPE)
+	buf.WriteRune(php_serialize.DELIMITER_OBJECT_LEFT)
+
+	for _, k := range pe.data.names {
+		// there is no way to write a variable without value into an array
+		if pe.data.IsUndefined(k) {
+			continue
+		}
+		if err = pe.encoder.EncodeTo(buf, k); err != nil {
+			break
+		}
+		if err = pe.data.encodeValue(buf, k, pe.encoder); err != nil {
+			err = fmt.Errorf("php_session: error during encode value for %q: %v", k, err)
+			break
+		}
+	}
+
+	buf.WriteRune(php_serialize.DELIMITER_OBJECT_RIGHT)
+	return err
+}
@@ -0,0 +1,64 @@
+package php_session_decoder
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestSerializeEncodeValue(t *testing.T) {
+	data := NewPhpSession().Set("login_ok", true)
+
+	encoder := NewPhpSerializeEncoder(data)
+	if result, err := encoder.Encode(); err != nil {
+		t.Errorf("Can not encode serialized session %#v \n", err)
+	} else if result != "a:1:{s:8:\"login_ok\";b:1;}" {
+		t.Errorf("Boolean value was encoded incorrectly %v \n", result)
+	}
+}
+
+func TestSerializeEncodeEmpty(t *testing.T) {
+	if result, err := EncodeSerialize(NewPhpSession()); err != nil {
+		t.Errorf("Can not encode serialized session %#v \n", err)
+	} else if result != "a:0:{}" {
+		t.Errorf("Empty session was encoded incorrectly %v \n", result)
+	}
+}
+
+func TestSerializeEncodeRoundTrip(t *testing.T) {
+	data := NewPhpSession().Set("inteiro", 34).Set("a|b", "text")
+
+	if encoded, err := EncodeSerialize(data); err != nil {
+		t.Errorf("Can not encode serialized session %#v \n", err)
+	} else if result, err := DecodeSerialize(encoded); err != nil {
+		t.Errorf("Can not decode serialized session %#v \n", err)
+	} else if result.Len() != 2 {
+		t.Errorf("Session was encoded incorrectly %q \n", encoded)
+	} else if v, _ := result.Get("inteiro"); v != 34 {
+		t.Errorf("Session was encoded incorrectly %q \n", encoded)
+	} else if v, _ := result.Get("a|b"); v != "text" {
+		t.Errorf("Session was encoded incorrectly %q \n", encoded)
+	}
+}
+
+func TestSerializeEncodeUndefinedValue(t *testing.T) {
+	data := NewPhpSession().SetUndefined("undef").Set("inteiro", 34)
+
+	if result, err := EncodeSerialize(data); err != nil {
+		t.Errorf("Can not encode serialized session %#v \n", err)
+	} else if result != "a:1:{s:7:\"inteiro\";i:34;}" {
+		t.Errorf("Undefined value should be skipped %v \n", result)
+	}
+}
+
+func TestSerializeEncodeTo(t *testing.T) {
+	var buffer bytes.Buffer
+	data := NewPhpSession().Set("login_ok", true)
+
+	encoder := NewPhpSerializeEncoder(data)
+	if err := encoder.EncodeTo(io.MultiWriter(&buffer)); err != nil {
+		t.Errorf("Can not encode serialized session to writer %#v \n", err)
+	} else if buffer.String() != "a:1:{s:8:\"login_ok\";b:1;}" {
+		t.Errorf("Serialized session was encoded to writer incorrectly %v \n", buffer.String())
+	}
+}
@@ -0,0 +1,230 @@
+package php_session_decoder
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/solidwall/php_session_decoder/php_serialize"
+)
+
+// PhpUndefined is the value of a variable which was registered in the session
+// but never defined. Unlike nil it has no serialized value at all.
+type PhpUndefined struct{}
+
+// PhpSession holds session variables in the order they were decoded or set.
+// Decoders keep the original serialized value of every variable, so encoders
+// write unmodified variables back byte by byte. The zero value is an empty session.
+type PhpSession struct {
+	names      []string
+	values     map[string]php_serialize.PhpValue
+	raw        map[string]string
+	decodeFunc php_serialize.SerializedDecodeFunc
+	// set holds variables passed to Set since decoding, deleted holds decoded variables passed to Delete
+	set     map[string]bool
+	deleted map[string]bool
+	// compared holds results of changed while the session is saved, see compare
+	compared map[string]bool
+}
+
+func NewPhpSession() *PhpSession {
+	ps := &PhpSession{}
+	ps.init()
+	return ps
+}
+
+// init creates maps of the zero value session.
+func (ps *PhpSession) init() {
+	if ps.values == nil {
+		ps.values = make(map[string]php_serialize.PhpValue)
+		ps.raw = make(map[string]string)
+		ps.set = make(map[string]bool)
+		ps.deleted = make(map[string]bool)
+	}
+}
+
+func (ps *PhpSession) Len() int {
+	return len(ps.names)
+}
+
+// Names returns names of variables in the order they will be encoded.
+func (ps *PhpSession) Names() []string {
+	return append([]string(nil), ps.names...)
+}
+
+func (ps *PhpSession) Get(name string) (v php_serialize.PhpValue, ok bool) {
+	v, ok = ps.values[name]
+	return
+}
+
+// Set replaces the value of existing variable in place or appends a new one.
+func (ps *PhpSession) Set(name string, value php_serialize.PhpValue) *PhpSession {
+	ps.init()
+	if _, ok := ps.values[name]; !ok {
+		ps.names = append(ps.names, name)
+	}
+	ps.values[name] = value
+	ps.set[name] = true
+	delete(ps.deleted, name)
+	return ps
+}
+
+func (ps *PhpSession) SetUndefined(name string) *PhpSession {
+	return ps.Set(name, PhpUndefined{})
+}
+
+func (ps *PhpSession) IsUndefined(name string) bool {
+	_, ok := ps.values[name].(PhpUndefined)
+	return ok
+}
+
+func (ps *PhpSession) Delete(name string) *PhpSession {
+	if _, ok := ps.values[name]; !ok {
+		return ps
+	}
+	for i, n := range ps.names {
+		if n == name {
+			ps.names = append(ps.names[:i], ps.names[i+1:]...)
+			break
+		}
+	}
+	if _, ok := ps.raw[name]; ok {
+		ps.deleted[name] = true
+	}
+	delete(ps.values, name)
+	delete(ps.raw, name)
+	delete(ps.set, name)
+	return ps
+}
+
+// Changed reports whether the session would be encoded differently than it was decoded,
+// like PHP compares session data for session.lazy_write.
+func (ps *PhpSession) Changed() bool {
+	if len(ps.deleted) > 0 {
+		return true
+	}
+	for _, name := range ps.names {
+		if ps.changed(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// ChangedNames returns variables which were added, replaced with different
+// values or mutated in place since decoding.
+func (ps *PhpSession) ChangedNames() []string {
+	var names []string
+	for _, name := range ps.names {
+		if ps.changed(name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// DeletedNames returns decoded variables which were deleted.
+func (ps *PhpSession) DeletedNames() []string {
+	var names []string
+	for name := range ps.deleted {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// nameFilter selects variables for DecodeKeys, nil filter selects all of them.
+type nameFilter map[string]bool
+
+func newNameFilter(names []string) nameFilter {
+	filter := make(nameFilter, len(names))
+	for _, name := range names {
+		filter[name] = true
+	}
+	return filter
+}
+
+func (nf nameFilter) match(name string) bool {
+	return nf == nil || nf[name]
+}
+
+// done reports whether all selected variables are already decoded.
+func (nf nameFilter) done(res *PhpSession) bool {
+	return nf != nil && res.Len() == len(nf)
+}
+
+func (ps *PhpSession) countUndefined() (count int) {
+	for _, name := range ps.names {
+		if ps.IsUndefined(name) {
+			count++
+		}
+	}
+	return
+}
+
+func (ps *PhpSession) setDecoded(name string, value php_serialize.PhpValue, raw string) {
+	ps.init()
+	if _, ok := ps.values[name]; !ok {
+		ps.names = append(ps.names, name)
+	}
+	ps.values[name] = value
+	ps.raw[name] = raw
+}
+
+// compare compares every variable once and keeps the results until release,
+// so Save decides whether to write the session and encodes it without decoding
+// original values again.
+func (ps *PhpSession) compare() bool {
+	ps.compared = nil
+	compared := make(map[string]bool, len(ps.names))
+	changed := len(ps.deleted) > 0
+	for _, name := range ps.names {
+		compared[name] = ps.changed(name)
+		changed = changed || compared[name]
+	}
+	ps.compared = compared
+	return changed
+}
+
+func (ps *PhpSession) release() {
+	ps.compared = nil
+}
+
+// changed compares the variable with its original serialized value. Scalars
+// can't be mutated in place, so they are compared only after Set.
+func (ps *PhpSession) changed(name string) bool {
+	if changed, ok := ps.compared[name]; ok {
+		return changed
+	}
+	raw, ok := ps.raw[name]
+	if !ok {
+		return true
+	}
+
+	value := ps.values[name]
+	if !ps.set[name] {
+		switch value.(type) {
+		case nil, bool, int, float64, string, PhpUndefined:
+			return false
+		}
+	}
+	if _, ok := value.(PhpUndefined); ok {
+		return raw != ""
+	}
+
+	decoder := php_serialize.NewUnSerializer(raw)
+	if ps.decodeFunc != nil {
+		decoder.SetSerializedDecodeFunc(ps.decodeFunc)
+	}
+	original, err := decoder.Decode()
+	return err != nil || !reflect.DeepEqual(original, value)
+}
+
+// encodeValue writes the original serialized value of unchanged variables,
+// only changed ones are serialized again.
+func (ps *PhpSession) encodeValue(w php_serialize.Writer, name string, encoder *php_serialize.Serializer) error {
+	if !ps.changed(name) {
+		_, err := w.WriteString(ps.raw[name])
+		return err
+	}
+	return encoder.EncodeTo(w, ps.values[name])
+}
@@ -0,0 +1,179 @@
+package php_session_decoder
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/solidwall/php_session_decoder/php_serialize"
+)
+
+func TestSessionOrder(t *testing.T) {
+	session := NewPhpSession().Set("c", 1).Set("a", 2).Set("b", 3)
+	session.Set("a", 4).Delete("c").Set("c", 5)
+
+	if names := session.Names(); !reflect.DeepEqual(names, []string{"a", "b", "c"}) {
+		t.Errorf("Order of variables is incorrect: %v\n", names)
+	}
+	if v, ok := session.Get("a"); !ok || v != 4 {
+		t.Errorf("Value was replaced incorrectly: %v\n", v)
+	}
+	if result, err := Encode(session); err != nil {
+		t.Errorf("Can not encode session %#v \n", err)
+	} else if result != "a|i:4;b|i:3;c|i:5;" {
+		t.Errorf("Session was encoded in incorrect order %v\n", result)
+	}
+}
+
+func TestSessionZeroValue(t *testing.T) {
+	var session PhpSession
+	session.Delete("a").SetUndefined("u").Set("a", 1).Delete("u")
+
+	if !session.Changed() {
+		t.Errorf("Zero value session should be changed after Set\n")
+	}
+	if result, err := Encode(&session); err != nil {
+		t.Errorf("Can not encode session %#v \n", err)
+	} else if result != "a|i:1;" {
+		t.Errorf("Zero value session was encoded incorrectly %v\n", result)
+	}
+}
+
+func TestSessionRoundTripExact(t *testing.T) {
+	cases := map[Format]string{
+		FORMAT_PHP:           "z|d:0.1;arr|a:3:{s:1:\"b\";i:1;s:1:\"a\";i:2;i:7;d:1.5;}a|s:1:\"x\";",
+		FORMAT_PHP_BINARY:    "\x01zd:0.1;\x03arra:3:{s:1:\"b\";i:1;s:1:\"a\";i:2;i:7;d:1.5;}\x01as:1:\"x\";",
+		FORMAT_PHP_SERIALIZE: "a:3:{s:1:\"z\";d:0.1;s:3:\"arr\";a:3:{s:1:\"b\";i:1;s:1:\"a\";i:2;i:7;d:1.5;}s:1:\"a\";s:1:\"x\";}",
+	}
+
+	for format, data := range cases {
+		if session, err := DecodeFormat(data, format); err != nil {
+			t.Errorf("Can not decode %q session %#v \n", format, err)
+		} else if result, err := EncodeFormat(session, format); err != nil {
+			t.Errorf("Can not encode %q session %#v \n", format, err)
+		} else if result != data {
+			t.Errorf("Unmodified %q session was encoded incorrectly, expected: %q, have got: %q\n", format, data, result)
+		}
+	}
+}
+
+func TestSessionRoundTripModified(t *testing.T) {
+	session, err := Decode("a|a:2:{s:1:\"b\";i:1;s:1:\"a\";i:2;}b|d:0.1;c|i:1;")
+	if err != nil {
+		t.Fatalf("Can not decode session %#v \n", err)
+	}
+
+	v, _ := session.Get("a")
+	delete(v.(php_serialize.PhpArray), "b")
+	session.Set("c", 2)
+
+	if result, err := Encode(session); err != nil {
+		t.Errorf("Can not encode session %#v \n", err)
+	} else if result != "a|a:1:{s:1:\"a\";i:2;}b|d:0.1;c|i:2;" {
+		t.Errorf("Modified session was encoded incorrectly %v\n", result)
+	}
+}
+
+func TestSessionChanged(t *testing.T) {
+	session, err := Decode(`a|i:1;arr|a:1:{s:1:"x";i:1;}!u|z|d:0.1;`)
+	if err != nil {
+		t.Fatalf("Can not decode session %#v \n", err)
+	}
+	if session.Changed() {
+		t.Errorf("Decoded session should not be changed: %v\n", session.ChangedNames())
+	}
+
+	session.Set("a", 1).SetUndefined("u")
+	if session.Changed() {
+		t.Errorf("Session with the same values should not be changed: %v\n", session.ChangedNames())
+	}
+
+	v, _ := session.Get("arr")
+	v.(php_serialize.PhpArray)["x"] = 2
+	session.Set("a", 2).Delete("z")
+	if !session.Changed() {
+		t.Errorf("Modified session should be changed\n")
+	}
+	if names := session.ChangedNames(); !reflect.DeepEqual(names, []string{"a", "arr"}) {
+		t.Errorf("Changed variables are incorrect: %v\n", names)
+	}
+	if names := session.DeletedNames(); !reflect.DeepEqual(names, []string{"z"}) {
+		t.Errorf("Deleted variables are incorrect: %v\n", names)
+	}
+
+	if !NewPhpSession().Set("a", 1).Changed() {
+		t.Errorf("New variables should be changed\n")
+	}
+}
+
+func TestSaveUnchanged(t *testing.T) {
+	store := &touchingStore{memoryStore: newMemoryStore()}
+	store.sessions["abc"] = "z|d:0.1;a|i:1;"
+
+	session, err := Load(store, "abc", FORMAT_PHP)
+	if err != nil {
+		t.Fatalf("Can not load session %#v \n", err)
+	}
+	if err = Save(store, "abc", session, FORMAT_PHP); err != nil {
+		t.Errorf("Can not save session %#v \n", err)
+	}
+	if store.writes != 0 || store.touches != 1 {
+		t.Errorf("Unchanged session should be touched only: %d writes, %d touches\n", store.writes, store.touches)
+	}
+
+	session.Set("a", 2)
+	if err = Save(store, "abc", session, FORMAT_PHP); err != nil {
+		t.Errorf("Can not save session %#v \n", err)
+	}
+	if store.writes != 1 || store.sessions["abc"] != "z|d:0.1;a|i:2;" {
+		t.Errorf("Changed session was written incorrectly: %q\n", store.sessions["abc"])
+	}
+}
+
+func TestSaveComparesOnce(t *testing.T) {
+	decodes := 0
+	decoder := NewPhpDecoder(`o|C:3:"Foo":6:{a:0:{}}arr|a:1:{s:1:"x";i:1;}a|i:1;`)
+	decoder.SetSerializedDecodeFunc(func(data string) (php_serialize.PhpValue, error) {
+		decodes++
+		return php_serialize.UnSerialize(data)
+	})
+	session, err := decoder.Decode()
+	if err != nil {
+		t.Fatalf("Can not decode session %#v \n", err)
+	}
+
+	store := &touchingStore{memoryStore: newMemoryStore()}
+	session.Set("a", 2)
+	decodes = 0
+	if err = Save(store, "abc", session, FORMAT_PHP); err != nil {
+		t.Errorf("Can not save session %#v \n", err)
+	}
+	if decodes != 1 {
+		t.Errorf("Unchanged object should be compared once: %d decodes\n", decodes)
+	}
+	if store.sessions["abc"] != `o|C:3:"Foo":6:{a:0:{}}arr|a:1:{s:1:"x";i:1;}a|i:2;` {
+		t.Errorf("Session was written incorrectly: %q\n", store.sessions["abc"])
+	}
+
+	// results are not kept after saving, mutations are still detected
+	v, _ := session.Get("arr")
+	v.(php_serialize.PhpArray)["x"] = 2
+	if names := session.ChangedNames(); !reflect.DeepEqual(names, []string{"arr", "a"}) {
+		t.Errorf("Changed variables are incorrect: %v\n", names)
+	}
+}
+
+type touchingStore struct {
+	*memoryStore
+	writes  int
+	touches int
+}
+
+func (s *touchingStore) Write(id string, data string) error {
+	s.writes++
+	return s.memoryStore.Write(id, data)
+}
+
+func (s *touchingStore) Touch(id string) error {
+	s.touches++
+	return nil
+}
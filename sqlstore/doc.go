@@ -0,0 +1,2 @@
+// Package sqlstore provides session store on top of database/sql compatible with common PHP session tables.
+package sqlstore
@@ -0,0 +1,258 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeDriver is an in-memory database which understands only the queries built by Store.
+type fakeDriver struct {
+	mu        sync.Mutex
+	databases map[string]*fakeDatabase
+}
+
+type fakeDatabase struct {
+	mu      sync.Mutex
+	rows    []map[string]driver.Value
+	queries []string
+	// changedRows counts only changed rows in UPDATE like MySQL does without clientFoundRows
+	changedRows bool
+}
+
+var testDriver = &fakeDriver{databases: map[string]*fakeDatabase{}}
+
+func init() {
+	sql.Register("sqlstore_fake", testDriver)
+}
+
+// newFakeDatabase opens new empty database for the test.
+func newFakeDatabase(t *testing.T) (*sql.DB, *fakeDatabase) {
+	database := &fakeDatabase{}
+	testDriver.mu.Lock()
+	testDriver.databases[t.Name()] = database
+	testDriver.mu.Unlock()
+
+	db, err := sql.Open("sqlstore_fake", t.Name())
+	if err != nil {
+		t.Fatalf("Can not open database: %v\n", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, database
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if database, ok := d.databases[name]; ok {
+		return &fakeConn{database}, nil
+	}
+	return nil, fmt.Errorf("unknown database %q", name)
+}
+
+// insert adds the row, the first column is the primary key.
+func (db *fakeDatabase) insert(columns []string, values []interface{}) {
+	row := map[string]driver.Value{}
+	for i, column := range columns {
+		row[column] = values[i]
+	}
+	db.mu.Lock()
+	db.rows = append(db.rows, row)
+	db.mu.Unlock()
+}
+
+func (db *fakeDatabase) find(column string, value driver.Value) map[string]driver.Value {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, row := range db.rows {
+		if equalValues(row[column], value) {
+			return row
+		}
+	}
+	return nil
+}
+
+func (db *fakeDatabase) lastQuery() string {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.queries[len(db.queries)-1]
+}
+
+var (
+	reSelect       = regexp.MustCompile(`^SELECT (.+?) FROM \w+ WHERE (.+)$`)
+	reUpdate       = regexp.MustCompile(`^UPDATE \w+ SET (.+?) WHERE (.+)$`)
+	reInsert       = regexp.MustCompile(`^INSERT INTO \w+ \((.+)\) VALUES \(.+\)$`)
+	reDeleteEqual  = regexp.MustCompile(`^DELETE FROM \w+ WHERE (\w+) = \S+$`)
+	reDeleteBefore = regexp.MustCompile(`^DELETE FROM \w+ WHERE (\w+) < \S+$`)
+)
+
+type fakeConn struct {
+	db *fakeDatabase
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	c.db.mu.Lock()
+	c.db.queries = append(c.db.queries, query)
+	c.db.mu.Unlock()
+	return &fakeStmt{c.db, query}, nil
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+type fakeStmt struct {
+	db    *fakeDatabase
+	query string
+}
+
+func (s *fakeStmt) Close() error {
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	db := s.db
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var affected int64
+	if m := reUpdate.FindStringSubmatch(s.query); m != nil {
+		set := strings.Split(m[1], ", ")
+		for _, row := range db.rows {
+			if !matchWhere(row, m[2], args[len(set):]) {
+				continue
+			}
+			changed := false
+			for i, assignment := range set {
+				column := strings.Fields(assignment)[0]
+				changed = changed || !equalValues(row[column], args[i])
+				row[column] = args[i]
+			}
+			if changed || !db.changedRows {
+				affected++
+			}
+		}
+	} else if m := reInsert.FindStringSubmatch(s.query); m != nil {
+		columns := strings.Split(m[1], ", ")
+		for _, row := range db.rows {
+			if equalValues(row[columns[0]], args[0]) {
+				return nil, errors.New("duplicate key")
+			}
+		}
+		row := map[string]driver.Value{}
+		for i, column := range columns {
+			row[column] = args[i]
+		}
+		db.rows = append(db.rows, row)
+		affected = 1
+	} else if m := reDeleteEqual.FindStringSubmatch(s.query); m != nil {
+		affected = db.delete(func(row map[string]driver.Value) bool { return equalValues(row[m[1]], args[0]) })
+	} else if m := reDeleteBefore.FindStringSubmatch(s.query); m != nil {
+		affected = db.delete(func(row map[string]driver.Value) bool { return row[m[1]].(int64) < args[0].(int64) })
+	} else {
+		return nil, fmt.Errorf("unsupported query %q", s.query)
+	}
+	return driver.RowsAffected(affected), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	m := reSelect.FindStringSubmatch(s.query)
+	if m == nil {
+		return nil, fmt.Errorf("unsupported query %q", s.query)
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	rows := &fakeRows{columns: strings.Split(m[1], ", ")}
+	for _, row := range s.db.rows {
+		if !matchWhere(row, m[2], args) {
+			continue
+		}
+		values := make([]driver.Value, len(rows.columns))
+		for i, column := range rows.columns {
+			if column == "1" {
+				values[i] = int64(1)
+			} else {
+				values[i] = row[column]
+			}
+		}
+		rows.values = append(rows.values, values)
+	}
+	return rows, nil
+}
+
+func (db *fakeDatabase) delete(match func(row map[string]driver.Value) bool) (affected int64) {
+	rows := db.rows[:0]
+	for _, row := range db.rows {
+		if match(row) {
+			affected++
+		} else {
+			rows = append(rows, row)
+		}
+	}
+	db.rows = rows
+	return
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func equalValues(a, b driver.Value) bool {
+	return fmt.Sprintf("%s", a) == fmt.Sprintf("%s", b)
+}
+
+// matchWhere evaluates conditions like `a = ? AND (b = ? OR c <= ?)`, args are used in order.
+func matchWhere(row map[string]driver.Value, where string, args []driver.Value) bool {
+	for _, term := range strings.Split(where, " AND ") {
+		matched := false
+		for _, atom := range strings.Split(strings.Trim(term, "()"), " OR ") {
+			fields := strings.Fields(atom)
+			value, arg := row[fields[0]], args[0]
+			args = args[1:]
+			switch fields[1] {
+			case "=":
+				matched = matched || equalValues(value, arg)
+			case "<":
+				matched = matched || value.(int64) < arg.(int64)
+			case "<=":
+				matched = matched || value.(int64) <= arg.(int64)
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
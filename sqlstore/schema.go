@@ -0,0 +1,43 @@
+package sqlstore
+
+// Schema maps the session table, optional columns are ignored when empty.
+type Schema struct {
+	Table      string
+	IdColumn   string
+	DataColumn string
+	// TimeColumn keeps unix time of the last write.
+	TimeColumn string
+	// ExpiryColumn keeps unix time when the session expires.
+	ExpiryColumn string
+	// Base64 makes the data column keep base64 encoded session data.
+	Base64 bool
+}
+
+var (
+	// SymfonySchema is the table of Symfony PdoSessionHandler.
+	SymfonySchema = Schema{
+		Table:        "sessions",
+		IdColumn:     "sess_id",
+		DataColumn:   "sess_data",
+		TimeColumn:   "sess_time",
+		ExpiryColumn: "sess_lifetime",
+	}
+
+	// LaravelSchema is the table of Laravel database session driver, its payload
+	// is base64 encoded session in php_serialize format.
+	LaravelSchema = Schema{
+		Table:      "sessions",
+		IdColumn:   "id",
+		DataColumn: "payload",
+		TimeColumn: "last_activity",
+		Base64:     true,
+	}
+
+	// SimpleSchema is the usual table of hand-written session handlers.
+	SimpleSchema = Schema{
+		Table:      "sessions",
+		IdColumn:   "id",
+		DataColumn: "data",
+		TimeColumn: "timestamp",
+	}
+)
@@ -0,0 +1,296 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/solidwall/php_session_decoder"
+)
+
+// QuestionPlaceholder is used by MySQL and SQLite drivers.
+func QuestionPlaceholder(n int) string {
+	return "?"
+}
+
+// DollarPlaceholder is used by PostgreSQL drivers.
+func DollarPlaceholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+// Options of the store, zero values are replaced with defaults.
+type Options struct {
+	// Schema is SimpleSchema by default.
+	Schema Schema
+	// Lifetime is session.gc_maxlifetime, it is used for expiry column or together with time column.
+	Lifetime time.Duration
+	// Placeholder returns placeholder of n-th query parameter starting from 1, QuestionPlaceholder by default.
+	Placeholder func(n int) string
+}
+
+// Store reads and writes sessions in the database table described by Schema.
+type Store struct {
+	db      *sql.DB
+	options Options
+	now     func() time.Time
+}
+
+var (
+	_ php_session_decoder.Store   = (*Store)(nil)
+	_ php_session_decoder.Swapper = (*Store)(nil)
+	_ php_session_decoder.Toucher = (*Store)(nil)
+)
+
+func NewStore(db *sql.DB, options Options) *Store {
+	if options.Schema == (Schema{}) {
+		options.Schema = SimpleSchema
+	}
+	if options.Lifetime == 0 {
+		options.Lifetime = 1440 * time.Second
+	}
+	if options.Placeholder == nil {
+		options.Placeholder = QuestionPlaceholder
+	}
+
+	return &Store{
+		db:      db,
+		options: options,
+		now:     time.Now,
+	}
+}
+
+// Read returns ErrNotFound for expired sessions which are not collected yet.
+func (s *Store) Read(id string) (string, error) {
+	schema := s.options.Schema
+	columns := []string{schema.DataColumn}
+	if schema.ExpiryColumn != "" {
+		columns = append(columns, schema.ExpiryColumn)
+	} else if schema.TimeColumn != "" {
+		columns = append(columns, schema.TimeColumn)
+	}
+
+	var (
+		data []byte
+		unix sql.NullInt64
+	)
+	dest := []interface{}{&data}
+	if len(columns) > 1 {
+		dest = append(dest, &unix)
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = %s", strings.Join(columns, ", "), schema.Table, schema.IdColumn, s.options.Placeholder(1))
+	err := s.db.QueryRow(query, id).Scan(dest...)
+	if err == sql.ErrNoRows {
+		return "", php_session_decoder.ErrNotFound
+	} else if err != nil {
+		return "", err
+	}
+
+	if unix.Valid && !s.expiredBefore(unix.Int64).After(s.now()) {
+		return "", php_session_decoder.ErrNotFound
+	}
+	return s.decodeData(data)
+}
+
+// Write updates the session row or inserts a new one.
+func (s *Store) Write(id string, data string) error {
+	encoded := s.encodeData(data)
+	columns, values := s.timeValues()
+	columns = append([]string{s.options.Schema.DataColumn}, columns...)
+	values = append([]interface{}{encoded}, values...)
+
+	updated, err := s.update(id, columns, values, "")
+	if err != nil || updated {
+		return err
+	}
+
+	if err = s.insert(id, columns, values); err != nil {
+		// the row may be inserted concurrently, in this case it has to be updated
+		if updated, updateErr := s.update(id, columns, values, ""); updateErr != nil || !updated {
+			return err
+		}
+	}
+	return nil
+}
+
+// CompareAndSwap writes the session only if its stored data is still old,
+// expired and missing sessions are the same as empty data.
+func (s *Store) CompareAndSwap(id string, old string, new string) error {
+	schema := s.options.Schema
+	columns, values := s.timeValues()
+	columns = append([]string{schema.DataColumn}, columns...)
+	values = append([]interface{}{s.encodeData(new)}, values...)
+
+	condition := schema.DataColumn + " = %s"
+	conditionValues := []interface{}{s.encodeData(old)}
+	if column, limit := s.expiryLimit(); old == "" && column != "" {
+		condition += " OR " + column + " <= %s"
+		conditionValues = append(conditionValues, limit)
+	}
+
+	updated, err := s.update(id, columns, values, condition, conditionValues...)
+	if err != nil || updated {
+		return err
+	}
+	if old == "" && s.insert(id, columns, values) == nil {
+		return nil
+	}
+	return fmt.Errorf("%w: %q", php_session_decoder.ErrConflict, id)
+}
+
+// Touch updates time columns of the session without writing its data.
+func (s *Store) Touch(id string) error {
+	columns, values := s.timeValues()
+	if len(columns) == 0 {
+		return nil
+	}
+	_, err := s.update(id, columns, values, "")
+	return err
+}
+
+func (s *Store) Destroy(id string) error {
+	schema := s.options.Schema
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s = %s", schema.Table, schema.IdColumn, s.options.Placeholder(1))
+	_, err := s.db.Exec(query, id)
+	return err
+}
+
+// GC deletes expired sessions and returns the number of them.
+func (s *Store) GC() (int64, error) {
+	column, limit := s.expiryLimit()
+	if column == "" {
+		return 0, nil
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s < %s", s.options.Schema.Table, column, s.options.Placeholder(1))
+	res, err := s.db.Exec(query, limit)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// expiryLimit returns the column and its value up to which sessions are expired.
+func (s *Store) expiryLimit() (string, int64) {
+	schema := s.options.Schema
+	if schema.ExpiryColumn != "" {
+		return schema.ExpiryColumn, s.now().Unix()
+	} else if schema.TimeColumn != "" {
+		return schema.TimeColumn, s.now().Add(-s.options.Lifetime).Unix()
+	}
+	return "", 0
+}
+
+// expiredBefore returns the expiry time of the session from the value of expiry or time column.
+func (s *Store) expiredBefore(unix int64) time.Time {
+	t := time.Unix(unix, 0)
+	if s.options.Schema.ExpiryColumn == "" {
+		t = t.Add(s.options.Lifetime)
+	}
+	return t
+}
+
+func (s *Store) timeValues() (columns []string, values []interface{}) {
+	schema := s.options.Schema
+	now := s.now()
+	if schema.ExpiryColumn != "" {
+		columns = append(columns, schema.ExpiryColumn)
+		values = append(values, now.Add(s.options.Lifetime).Unix())
+	}
+	if schema.TimeColumn != "" {
+		columns = append(columns, schema.TimeColumn)
+		values = append(values, now.Unix())
+	}
+	return
+}
+
+// update sets columns of the session row, condition may restrict the row more,
+// its placeholders are marked with %s. It reports whether the row was found.
+func (s *Store) update(id string, columns []string, values []interface{}, condition string, conditionValues ...interface{}) (bool, error) {
+	schema := s.options.Schema
+	set := make([]string, len(columns))
+	for i, column := range columns {
+		set[i] = column + " = " + s.options.Placeholder(i+1)
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s", schema.Table, strings.Join(set, ", "), s.where(len(columns)+1, condition, len(conditionValues)))
+	args := append(append(append([]interface{}{}, values...), id), conditionValues...)
+	res, err := s.db.Exec(query, args...)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil || affected > 0 {
+		return affected > 0, err
+	}
+
+	// MySQL counts only changed rows unless clientFoundRows is set, so the row
+	// which already has the same values has to be looked up
+	return s.exists(id, columns, values, condition, conditionValues...)
+}
+
+// exists reports whether the session row has given values and matches the condition.
+func (s *Store) exists(id string, columns []string, values []interface{}, condition string, conditionValues ...interface{}) (bool, error) {
+	schema := s.options.Schema
+	where := s.where(1, condition, len(conditionValues))
+	for i, column := range columns {
+		where += " AND " + column + " = " + s.options.Placeholder(len(conditionValues)+2+i)
+	}
+
+	query := fmt.Sprintf("SELECT 1 FROM %s WHERE %s", schema.Table, where)
+	args := append(append([]interface{}{id}, conditionValues...), values...)
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	found := rows.Next()
+	return found, rows.Err()
+}
+
+// where matches the session row by id and the condition, placeholders are numbered from n.
+func (s *Store) where(n int, condition string, conditionValues int) string {
+	where := s.options.Schema.IdColumn + " = " + s.options.Placeholder(n)
+	if condition != "" {
+		placeholders := make([]interface{}, conditionValues)
+		for i := range placeholders {
+			placeholders[i] = s.options.Placeholder(n + 1 + i)
+		}
+		where += " AND (" + fmt.Sprintf(condition, placeholders...) + ")"
+	}
+	return where
+}
+
+func (s *Store) insert(id string, columns []string, values []interface{}) error {
+	schema := s.options.Schema
+	columns = append([]string{schema.IdColumn}, columns...)
+	placeholders := make([]string, len(columns))
+	for i := range columns {
+		placeholders[i] = s.options.Placeholder(i + 1)
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", schema.Table, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+	_, err := s.db.Exec(query, append([]interface{}{id}, values...)...)
+	return err
+}
+
+func (s *Store) encodeData(data string) []byte {
+	if s.options.Schema.Base64 {
+		return []byte(base64.StdEncoding.EncodeToString([]byte(data)))
+	}
+	return []byte(data)
+}
+
+func (s *Store) decodeData(data []byte) (string, error) {
+	if !s.options.Schema.Base64 {
+		return string(data), nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil {
+		return "", fmt.Errorf("sqlstore: unable to decode base64 session data: %v", err)
+	}
+	return string(decoded), nil
+}
@@ -0,0 +1,187 @@
+package sqlstore
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/solidwall/php_session_decoder"
+	"github.com/solidwall/php_session_decoder/php_serialize"
+)
+
+func TestSymfonySchema(t *testing.T) {
+	db, database := newFakeDatabase(t)
+	store := NewStore(db, Options{Schema: SymfonySchema})
+	now := time.Unix(1700000000, 0)
+	store.now = func() time.Time { return now }
+
+	if _, err := store.Read("abc"); !errors.Is(err, php_session_decoder.ErrNotFound) {
+		t.Errorf("Missing session should not be found, have got: %v\n", err)
+	}
+
+	session := php_session_decoder.NewPhpSession().Set("_sf2_attributes", php_serialize.PhpArray{})
+	if err := php_session_decoder.Save(store, "abc", session, php_session_decoder.FORMAT_PHP); err != nil {
+		t.Errorf("Can not save session: %v\n", err)
+	}
+	row := database.find("sess_id", "abc")
+	if row == nil {
+		t.Fatalf("Session was not inserted\n")
+	}
+	if string(row["sess_data"].([]byte)) != "_sf2_attributes|a:0:{}" {
+		t.Errorf("Session data was written incorrectly: %q\n", row["sess_data"])
+	} else if row["sess_lifetime"] != now.Unix()+1440 || row["sess_time"] != now.Unix() {
+		t.Errorf("Session time was written incorrectly: %v\n", row)
+	}
+
+	now = now.Add(time.Minute)
+	if err := store.Write("abc", "a|i:1;"); err != nil {
+		t.Errorf("Can not update session: %v\n", err)
+	}
+	if data, err := store.Read("abc"); err != nil || data != "a|i:1;" {
+		t.Errorf("Session was updated incorrectly: %q, %v\n", data, err)
+	}
+	if row := database.find("sess_id", "abc"); row["sess_lifetime"] != now.Unix()+1440 {
+		t.Errorf("Session expiry was not updated: %v\n", row)
+	}
+
+	now = now.Add(1441 * time.Second)
+	if _, err := store.Read("abc"); !errors.Is(err, php_session_decoder.ErrNotFound) {
+		t.Errorf("Expired session should not be found, have got: %v\n", err)
+	}
+	if deleted, err := store.GC(); err != nil || deleted != 1 {
+		t.Errorf("Expired session was not collected: %v, %v\n", deleted, err)
+	}
+}
+
+func TestLaravelSchema(t *testing.T) {
+	db, database := newFakeDatabase(t)
+	store := NewStore(db, Options{Schema: LaravelSchema, Lifetime: 120 * time.Minute})
+	now := time.Unix(1700000000, 0)
+	store.now = func() time.Time { return now }
+
+	if err := store.Write("abc", `a:1:{s:6:"_token";s:3:"xyz";}`); err != nil {
+		t.Errorf("Can not write session: %v\n", err)
+	}
+	row := database.find("id", "abc")
+	if row == nil {
+		t.Fatalf("Session was not inserted\n")
+	}
+	if string(row["payload"].([]byte)) != "YToxOntzOjY6Il90b2tlbiI7czozOiJ4eXoiO30=" {
+		t.Errorf("Payload was not encoded with base64: %q\n", row["payload"])
+	} else if row["last_activity"] != now.Unix() {
+		t.Errorf("Last activity was written incorrectly: %v\n", row)
+	}
+
+	if session, err := php_session_decoder.Load(store, "abc", php_session_decoder.FORMAT_PHP_SERIALIZE); err != nil {
+		t.Errorf("Can not load session: %v\n", err)
+	} else if v, _ := session.Get("_token"); v != "xyz" {
+		t.Errorf("Session was loaded incorrectly: %#v\n", session)
+	}
+
+	now = now.Add(121 * time.Minute)
+	if _, err := store.Read("abc"); !errors.Is(err, php_session_decoder.ErrNotFound) {
+		t.Errorf("Expired session should not be found, have got: %v\n", err)
+	}
+	if deleted, err := store.GC(); err != nil || deleted != 1 {
+		t.Errorf("Expired session was not collected: %v, %v\n", deleted, err)
+	}
+
+	database.insert([]string{"id", "payload", "last_activity"}, []interface{}{"bad", []byte("!!!"), now.Unix()})
+	if _, err := store.Read("bad"); err == nil {
+		t.Errorf("Invalid base64 payload should not be read\n")
+	}
+}
+
+func TestSimpleSchema(t *testing.T) {
+	db, database := newFakeDatabase(t)
+	store := NewStore(db, Options{Placeholder: DollarPlaceholder})
+	now := time.Unix(1700000000, 0)
+	store.now = func() time.Time { return now }
+
+	if err := store.Write("abc", "a|i:1;"); err != nil {
+		t.Errorf("Can not write session: %v\n", err)
+	}
+	if query := database.lastQuery(); query != "INSERT INTO sessions (id, data, timestamp) VALUES ($1, $2, $3)" {
+		t.Errorf("Query was built incorrectly: %q\n", query)
+	}
+
+	now = now.Add(time.Minute)
+	if err := store.Touch("abc"); err != nil {
+		t.Errorf("Can not touch session: %v\n", err)
+	}
+	if query := database.lastQuery(); query != "UPDATE sessions SET timestamp = $1 WHERE id = $2" {
+		t.Errorf("Query was built incorrectly: %q\n", query)
+	}
+	if row := database.find("id", "abc"); row["timestamp"] != now.Unix() || string(row["data"].([]byte)) != "a|i:1;" {
+		t.Errorf("Session was touched incorrectly: %v\n", row)
+	}
+
+	if err := store.Destroy("abc"); err != nil {
+		t.Errorf("Can not destroy session: %v\n", err)
+	}
+	if row := database.find("id", "abc"); row != nil {
+		t.Errorf("Session was not destroyed: %v\n", row)
+	}
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	db, database := newFakeDatabase(t)
+	store := NewStore(db, Options{Schema: SymfonySchema})
+	now := time.Unix(1700000000, 0)
+	store.now = func() time.Time { return now }
+
+	if err := store.CompareAndSwap("abc", "", "a|i:1;"); err != nil {
+		t.Errorf("Missing session should be swapped as empty one: %v\n", err)
+	}
+	if err := store.CompareAndSwap("abc", "", "a|i:2;"); !errors.Is(err, php_session_decoder.ErrConflict) {
+		t.Errorf("Existing session should not be swapped as empty one: %v\n", err)
+	}
+	if err := store.CompareAndSwap("abc", "a|i:1;", "a|i:2;"); err != nil {
+		t.Errorf("Session should be swapped: %v\n", err)
+	}
+	if query := database.lastQuery(); query != "UPDATE sessions SET sess_data = ?, sess_lifetime = ?, sess_time = ? WHERE sess_id = ? AND (sess_data = ?)" {
+		t.Errorf("Query was built incorrectly: %q\n", query)
+	}
+	if err := store.CompareAndSwap("abc", "a|i:1;", "a|i:3;"); !errors.Is(err, php_session_decoder.ErrConflict) {
+		t.Errorf("Changed session should not be swapped: %v\n", err)
+	}
+	if data, _ := store.Read("abc"); data != "a|i:2;" {
+		t.Errorf("Session was swapped incorrectly: %q\n", data)
+	}
+
+	now = now.Add(time.Hour)
+	if err := store.CompareAndSwap("abc", "", "b|i:1;"); err != nil {
+		t.Errorf("Expired session should be swapped as empty one: %v\n", err)
+	}
+	if data, _ := store.Read("abc"); data != "b|i:1;" {
+		t.Errorf("Expired session was swapped incorrectly: %q\n", data)
+	}
+}
+
+func TestUnchangedRows(t *testing.T) {
+	db, database := newFakeDatabase(t)
+	database.changedRows = true
+	store := NewStore(db, Options{})
+	// the same data written within the same second doesn't change the row
+	now := time.Unix(1700000000, 0)
+	store.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if err := store.Write("abc", "a|i:1;"); err != nil {
+			t.Errorf("Can not write the same data again: %v\n", err)
+		}
+	}
+	if query := database.lastQuery(); query != "SELECT 1 FROM sessions WHERE id = ? AND data = ? AND timestamp = ?" {
+		t.Errorf("Query was built incorrectly: %q\n", query)
+	}
+	if len(database.rows) != 1 {
+		t.Errorf("Session should not be inserted twice: %v\n", database.rows)
+	}
+
+	if err := store.CompareAndSwap("abc", "a|i:1;", "a|i:1;"); err != nil {
+		t.Errorf("Session should be swapped with the same data: %v\n", err)
+	}
+	if err := store.CompareAndSwap("abc", "a|i:2;", "a|i:1;"); !errors.Is(err, php_session_decoder.ErrConflict) {
+		t.Errorf("Changed session should not be swapped: %v\n", err)
+	}
+}
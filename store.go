@@ -0,0 +1,48 @@
+package php_session_decoder
+
+import (
+	"errors"
+)
+
+var ErrNotFound = errors.New("php_session: Session not found")
+
+// Store keeps raw session data by session id, like PHP session save handlers do.
+// Read returns ErrNotFound when there is no session with given id.
+type Store interface {
+	Read(id string) (string, error)
+	Write(id string, data string) error
+	Destroy(id string) error
+}
+
+// Load reads session data from the store and decodes it in given format.
+func Load(store Store, id string, format Format) (*PhpSession, error) {
+	data, err := store.Read(id)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeFormat(data, format)
+}
+
+// Toucher is implemented by stores which can extend the lifetime of a session without writing it.
+type Toucher interface {
+	Touch(id string) error
+}
+
+// Save encodes session data in given format and writes it to the store.
+// Like PHP with session.lazy_write, unchanged sessions are not written,
+// only their TTL or timestamp is updated if the store is Toucher.
+func Save(store Store, id string, session *PhpSession, format Format) error {
+	defer session.release()
+	if !session.compare() {
+		if toucher, ok := store.(Toucher); ok {
+			return toucher.Touch(id)
+		}
+		return nil
+	}
+
+	data, err := EncodeFormat(session, format)
+	if err != nil {
+		return err
+	}
+	return store.Write(id, data)
+}
@@ -0,0 +1,2 @@
+// Package symfony provides access to session bags of Symfony applications.
+package symfony
@@ -0,0 +1,206 @@
+package symfony
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/solidwall/php_session_decoder"
+	"github.com/solidwall/php_session_decoder/php_serialize"
+)
+
+const (
+	// SECURITY_KEY_PREFIX is followed by the name of firewall context in the attribute with serialized token
+	SECURITY_KEY_PREFIX = "_security_"
+
+	tokenMaxDepth = 8
+)
+
+var ErrUnsupportedToken = errors.New("symfony: Unsupported security token")
+
+// UserIdentifierProperties are properties of user objects checked in this order
+// for the user identifier, the first string one is used.
+var UserIdentifierProperties = []string{"userIdentifier", "username", "email", "identifier"}
+
+// Token is the security token of authenticated user.
+type Token struct {
+	// Class is the token class, e.g. UsernamePasswordToken, PostAuthenticationToken or RememberMeToken.
+	Class    string
+	Firewall string
+	// UserIdentifier is empty when it can't be found in the user object.
+	UserIdentifier string
+	Roles          []string
+	// User is the string or the object stored as the user of the token.
+	User php_serialize.PhpValue
+}
+
+// SecurityToken decodes the token of the firewall context, php_session_decoder.ErrNotFound is returned
+// when there is no token.
+func (s *Session) SecurityToken(context string) (*Token, error) {
+	v, ok := s.bag(KEY_ATTRIBUTES)[SECURITY_KEY_PREFIX+context]
+	if !ok {
+		return nil, php_session_decoder.ErrNotFound
+	}
+	serialized, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: unexpected value of type %T", ErrUnsupportedToken, v)
+	}
+	return DecodeToken(serialized)
+}
+
+// SecurityContexts returns sorted names of firewall contexts with tokens.
+func (s *Session) SecurityContexts() []string {
+	var contexts []string
+	for _, name := range s.AttributeNames() {
+		if strings.HasPrefix(name, SECURITY_KEY_PREFIX) {
+			contexts = append(contexts, name[len(SECURITY_KEY_PREFIX):])
+		}
+	}
+	sort.Strings(contexts)
+	return contexts
+}
+
+// DecodeToken decodes serialized token of Symfony 3 to 7. Old tokens implement
+// Serializable and nest serialized parent data as strings, new ones use
+// __serialize and nest arrays, both end with AbstractToken data:
+// user, authenticated flag, roles, attributes and, since Symfony 4.3, role names.
+func DecodeToken(serialized string) (*Token, error) {
+	value, err := php_serialize.UnSerialize(serialized)
+	if err != nil {
+		return nil, err
+	}
+
+	token := &Token{}
+	var items []php_serialize.PhpValue
+	switch v := value.(type) {
+	case *php_serialize.PhpObject:
+		token.Class = v.GetClassName()
+		items, err = listItems(v.GetMembers())
+	case *php_serialize.PhpObjectSerialized:
+		token.Class = v.GetClassName()
+		items, err = listItems(v.GetData())
+	default:
+		err = fmt.Errorf("%w: unexpected value of type %T", ErrUnsupportedToken, value)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = token.decodeLayer(items, 0); err != nil {
+		return nil, fmt.Errorf("%w %s: %v", ErrUnsupportedToken, token.Class, err)
+	}
+	return token, nil
+}
+
+// decodeLayer reads data of the token class, its last item is data of the parent class
+// and the item before it is the firewall name.
+func (t *Token) decodeLayer(items []php_serialize.PhpValue, depth int) error {
+	if isAbstractToken(items) {
+		return t.decodeAbstract(items)
+	}
+	if depth >= tokenMaxDepth || len(items) == 0 {
+		return errors.New("token data is not found")
+	}
+
+	parent, err := listItems(items[len(items)-1])
+	if err != nil {
+		return err
+	}
+	if isAbstractToken(parent) && len(items) > 1 {
+		t.Firewall, _ = items[len(items)-2].(string)
+	}
+	return t.decodeLayer(parent, depth+1)
+}
+
+func isAbstractToken(items []php_serialize.PhpValue) bool {
+	if len(items) < 4 {
+		return false
+	}
+	_, ok := items[1].(bool)
+	return ok
+}
+
+func (t *Token) decodeAbstract(items []php_serialize.PhpValue) error {
+	t.User = items[0]
+	t.UserIdentifier = userIdentifier(items[0])
+
+	// role names replaced Role objects in Symfony 4.3
+	roles := items[2]
+	if len(items) > 4 {
+		roles = items[4]
+	}
+	list, _ := listItems(roles)
+	for _, role := range list {
+		if object, ok := role.(*php_serialize.PhpObject); ok {
+			role, _ = property(object.GetMembers(), "role")
+		}
+		if name, ok := role.(string); ok {
+			t.Roles = append(t.Roles, name)
+		}
+	}
+	return nil
+}
+
+func userIdentifier(user php_serialize.PhpValue) string {
+	var members php_serialize.PhpArray
+	switch user := user.(type) {
+	case string:
+		return user
+	case *php_serialize.PhpObject:
+		members = user.GetMembers()
+	case *php_serialize.PhpObjectSerialized:
+		// users implementing Serializable usually serialize arrays of properties
+		v, _ := php_serialize.UnSerialize(user.GetData())
+		members, _ = v.(php_serialize.PhpArray)
+	}
+
+	for _, name := range UserIdentifierProperties {
+		if v, ok := property(members, name); ok {
+			if identifier, ok := v.(string); ok && identifier != "" {
+				return identifier
+			}
+		}
+	}
+	return ""
+}
+
+// property returns public, protected or private property, the last one may be declared by any class.
+func property(members php_serialize.PhpArray, name string) (php_serialize.PhpValue, bool) {
+	if v, ok := members[name]; ok {
+		return v, true
+	}
+	for k, v := range members {
+		if key, ok := k.(string); ok && strings.HasPrefix(key, "\x00") && strings.HasSuffix(key, "\x00"+name) {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// listItems returns items of PHP list, serialized lists are decoded.
+func listItems(v php_serialize.PhpValue) ([]php_serialize.PhpValue, error) {
+	if serialized, ok := v.(string); ok {
+		var err error
+		if v, err = php_serialize.UnSerialize(serialized); err != nil {
+			return nil, err
+		}
+	}
+	if v == nil {
+		return nil, nil
+	}
+
+	array, ok := v.(php_serialize.PhpArray)
+	if !ok {
+		return nil, fmt.Errorf("expected list, got %T", v)
+	}
+	items := make([]php_serialize.PhpValue, len(array))
+	for i := range items {
+		item, ok := array[i]
+		if !ok {
+			return nil, fmt.Errorf("expected list, got array without index %d", i)
+		}
+		items[i] = item
+	}
+	return items, nil
+}
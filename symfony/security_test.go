@@ -0,0 +1,152 @@
+package symfony
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/solidwall/php_session_decoder"
+)
+
+const tokenNamespace = `Symfony\Component\Security\Core\Authentication\Token\`
+
+// helpers build serialized values, so the lengths are always correct
+func str(s string) string {
+	return fmt.Sprintf(`s:%d:"%s";`, len(s), s)
+}
+
+func list(items ...string) string {
+	var b strings.Builder
+	for i, item := range items {
+		fmt.Fprintf(&b, "i:%d;%s", i, item)
+	}
+	return fmt.Sprintf("a:%d:{%s}", len(items), b.String())
+}
+
+func object(class string, members ...string) string {
+	return fmt.Sprintf(`O:%d:"%s":%d:{%s}`, len(class), class, len(members)/2, strings.Join(members, ""))
+}
+
+func serializable(class string, data string) string {
+	return fmt.Sprintf(`C:%d:"%s":%d:{%s}`, len(class), class, len(data), data)
+}
+
+func private(class string, name string) string {
+	return str("\x00" + class + "\x00" + name)
+}
+
+func TestDecodeToken(t *testing.T) {
+	inMemoryUser := object(`Symfony\Component\Security\Core\User\InMemoryUser`,
+		private(`Symfony\Component\Security\Core\User\InMemoryUser`, "username"), str("admin"),
+		private(`Symfony\Component\Security\Core\User\InMemoryUser`, "enabled"), "b:1;",
+	)
+	entityUser := object(`App\Entity\User`,
+		private(`App\Entity\User`, "id"), "i:7;",
+		private(`App\Entity\User`, "email"), str("user@example.com"),
+	)
+	role := func(name string) string {
+		return object(`Symfony\Component\Security\Core\Role\Role`, private(`Symfony\Component\Security\Core\Role\Role`, "role"), str(name))
+	}
+	usernamePasswordToken6 := object(tokenNamespace+"UsernamePasswordToken",
+		"i:0;", "N;",
+		"i:1;", str("main"),
+		"i:2;", list(inMemoryUser, "b:1;", "N;", "a:0:{}", list(str("ROLE_ADMIN"), str("ROLE_USER"))),
+	)
+
+	cases := []struct {
+		name       string
+		serialized string
+		expected   Token
+	}{
+		{
+			"Symfony 6 UsernamePasswordToken",
+			usernamePasswordToken6,
+			Token{Class: tokenNamespace + "UsernamePasswordToken", Firewall: "main", UserIdentifier: "admin", Roles: []string{"ROLE_ADMIN", "ROLE_USER"}},
+		},
+		{
+			"Symfony 5 PostAuthenticationToken",
+			object(`Symfony\Component\Security\Http\Authenticator\Token\PostAuthenticationToken`,
+				"i:0;", str("api"),
+				"i:1;", list(entityUser, "b:1;", "N;", "a:0:{}", list(str("ROLE_USER"))),
+			),
+			Token{Class: `Symfony\Component\Security\Http\Authenticator\Token\PostAuthenticationToken`, Firewall: "api", UserIdentifier: "user@example.com", Roles: []string{"ROLE_USER"}},
+		},
+		{
+			"Symfony 4.4 RememberMeToken",
+			serializable(tokenNamespace+"RememberMeToken",
+				list(str("secret"), str("main"), list(str("john"), "b:1;", "a:0:{}", "a:0:{}", list(str("ROLE_USER")))),
+			),
+			Token{Class: tokenNamespace + "RememberMeToken", Firewall: "main", UserIdentifier: "john", Roles: []string{"ROLE_USER"}},
+		},
+		{
+			"Symfony 3 UsernamePasswordToken",
+			serializable(tokenNamespace+"UsernamePasswordToken",
+				list("N;", str("secured_area"), str(list(entityUser, "b:1;", list(role("ROLE_USER"), role("ROLE_EDITOR")), "a:0:{}"))),
+			),
+			Token{Class: tokenNamespace + "UsernamePasswordToken", Firewall: "secured_area", UserIdentifier: "user@example.com", Roles: []string{"ROLE_USER", "ROLE_EDITOR"}},
+		},
+		{
+			"Symfony 3 PostAuthenticationGuardToken",
+			serializable(`Symfony\Component\Security\Guard\Token\PostAuthenticationGuardToken`,
+				list(str("main"), str(list(str("anna"), "b:1;", list(role("ROLE_USER")), "a:0:{}"))),
+			),
+			Token{Class: `Symfony\Component\Security\Guard\Token\PostAuthenticationGuardToken`, Firewall: "main", UserIdentifier: "anna", Roles: []string{"ROLE_USER"}},
+		},
+		{
+			"Symfony 6 SwitchUserToken",
+			object(tokenNamespace+"SwitchUserToken",
+				"i:0;", usernamePasswordToken6,
+				"i:1;", str("/admin"),
+				"i:2;", list("N;", str("main"), list(entityUser, "b:1;", "N;", "a:0:{}", list(str("ROLE_USER"), str("ROLE_PREVIOUS_ADMIN")))),
+			),
+			Token{Class: tokenNamespace + "SwitchUserToken", Firewall: "main", UserIdentifier: "user@example.com", Roles: []string{"ROLE_USER", "ROLE_PREVIOUS_ADMIN"}},
+		},
+	}
+
+	for _, c := range cases {
+		token, err := DecodeToken(c.serialized)
+		if err != nil {
+			t.Errorf("%s: can not decode token: %v\n", c.name, err)
+			continue
+		}
+		token.User = nil
+		if !reflect.DeepEqual(*token, c.expected) {
+			t.Errorf("%s: token was decoded incorrectly: %#v\n", c.name, *token)
+		}
+	}
+
+	if _, err := DecodeToken(str("token")); !errors.Is(err, ErrUnsupportedToken) {
+		t.Errorf("String should not be decoded as token: %v\n", err)
+	}
+	if _, err := DecodeToken(object(tokenNamespace + "NullToken")); !errors.Is(err, ErrUnsupportedToken) {
+		t.Errorf("Token without data should not be decoded: %v\n", err)
+	}
+}
+
+func TestSecurityToken(t *testing.T) {
+	token := object(tokenNamespace+"UsernamePasswordToken",
+		"i:0;", "N;",
+		"i:1;", str("main"),
+		"i:2;", list(str("admin"), "b:1;", "N;", "a:0:{}", list(str("ROLE_ADMIN"))),
+	)
+	data := "_sf2_attributes|" + fmt.Sprintf("a:1:{%s%s}", str("_security_main"), str(token))
+	phpSession, err := php_session_decoder.Decode(data)
+	if err != nil {
+		t.Fatalf("Can not decode session: %v\n", err)
+	}
+	session := NewSession(phpSession)
+
+	if contexts := session.SecurityContexts(); !reflect.DeepEqual(contexts, []string{"main"}) {
+		t.Errorf("Security contexts are incorrect: %v\n", contexts)
+	}
+	if result, err := session.SecurityToken("main"); err != nil {
+		t.Errorf("Can not decode security token: %v\n", err)
+	} else if result.UserIdentifier != "admin" || result.Firewall != "main" {
+		t.Errorf("Security token was decoded incorrectly: %#v\n", result)
+	}
+	if _, err := session.SecurityToken("admin"); !errors.Is(err, php_session_decoder.ErrNotFound) {
+		t.Errorf("Missing token should not be found: %v\n", err)
+	}
+}
@@ -0,0 +1,246 @@
+package symfony
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/solidwall/php_session_decoder"
+	"github.com/solidwall/php_session_decoder/php_serialize"
+)
+
+const (
+	KEY_ATTRIBUTES = "_sf2_attributes"
+	KEY_FLASHES    = "_sf2_flashes"
+	KEY_META       = "_sf2_meta"
+
+	META_CREATED   = "c"
+	META_UPDATED   = "u"
+	META_LIFETIME  = "l"
+	NAMESPACE_CHAR = "/"
+)
+
+// Session provides access to bags Symfony keeps in the session. Bags are
+// copied on every change, so the session is encoded with the same structure
+// and only changed bags are serialized again.
+type Session struct {
+	session *php_session_decoder.PhpSession
+}
+
+func NewSession(session *php_session_decoder.PhpSession) *Session {
+	return &Session{session: session}
+}
+
+// PhpSession returns underlying session variables.
+func (s *Session) PhpSession() *php_session_decoder.PhpSession {
+	return s.session
+}
+
+// Get returns the attribute. Names with slashes address nested arrays like
+// NamespacedAttributeBag does, unless there is an attribute with such name.
+func (s *Session) Get(name string) (php_serialize.PhpValue, bool) {
+	attributes := s.bag(KEY_ATTRIBUTES)
+	if v, ok := attributes[name]; ok {
+		return v, true
+	}
+
+	var value php_serialize.PhpValue = attributes
+	for _, part := range strings.Split(name, NAMESPACE_CHAR) {
+		array, ok := value.(php_serialize.PhpArray)
+		if !ok {
+			return nil, false
+		}
+		if value, ok = array[part]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+func (s *Session) Has(name string) bool {
+	_, ok := s.Get(name)
+	return ok
+}
+
+// Set replaces the attribute, names with slashes are stored in nested arrays.
+func (s *Session) Set(name string, value php_serialize.PhpValue) {
+	attributes := s.bag(KEY_ATTRIBUTES)
+	if _, ok := attributes[name]; ok || !strings.Contains(name, NAMESPACE_CHAR) {
+		attributes[name] = value
+	} else {
+		setPath(attributes, strings.Split(name, NAMESPACE_CHAR), value)
+	}
+	s.session.Set(KEY_ATTRIBUTES, attributes)
+}
+
+// Remove deletes the attribute and returns its value.
+func (s *Session) Remove(name string) (php_serialize.PhpValue, bool) {
+	attributes := s.bag(KEY_ATTRIBUTES)
+	value, ok := attributes[name]
+	if ok {
+		delete(attributes, name)
+	} else if parts := strings.Split(name, NAMESPACE_CHAR); len(parts) > 1 {
+		value, ok = removePath(attributes, parts)
+	}
+	if ok {
+		s.session.Set(KEY_ATTRIBUTES, attributes)
+	}
+	return value, ok
+}
+
+// AttributeNames returns sorted names of top level attributes.
+func (s *Session) AttributeNames() []string {
+	return sortedKeys(s.bag(KEY_ATTRIBUTES))
+}
+
+// PeekFlashes returns messages of the type keeping them in the session.
+func (s *Session) PeekFlashes(flashType string) []php_serialize.PhpValue {
+	return listValues(s.bag(KEY_FLASHES)[flashType])
+}
+
+// GetFlashes returns messages of the type and removes them, like FlashBag::get does.
+func (s *Session) GetFlashes(flashType string) []php_serialize.PhpValue {
+	flashes := s.bag(KEY_FLASHES)
+	messages, ok := flashes[flashType]
+	if !ok {
+		return nil
+	}
+	delete(flashes, flashType)
+	s.session.Set(KEY_FLASHES, flashes)
+	return listValues(messages)
+}
+
+// AddFlash appends the message to messages of the type.
+func (s *Session) AddFlash(flashType string, message php_serialize.PhpValue) {
+	s.SetFlashes(flashType, append(s.PeekFlashes(flashType), message))
+}
+
+// SetFlashes replaces messages of the type.
+func (s *Session) SetFlashes(flashType string, messages []php_serialize.PhpValue) {
+	flashes := s.bag(KEY_FLASHES)
+	list := make(php_serialize.PhpArray, len(messages))
+	for i, message := range messages {
+		list[i] = message
+	}
+	flashes[flashType] = list
+	s.session.Set(KEY_FLASHES, flashes)
+}
+
+// FlashTypes returns sorted types of flash messages.
+func (s *Session) FlashTypes() []string {
+	return sortedKeys(s.bag(KEY_FLASHES))
+}
+
+// Created returns the time when the session was started.
+func (s *Session) Created() time.Time {
+	return s.metaTime(META_CREATED)
+}
+
+// LastUsed returns the time of the last request, Symfony updates it at most once per update threshold.
+func (s *Session) LastUsed() time.Time {
+	return s.metaTime(META_UPDATED)
+}
+
+// Lifetime returns the lifetime of the session cookie, zero means until the browser is closed.
+func (s *Session) Lifetime() time.Duration {
+	v := s.bag(KEY_META)[META_LIFETIME]
+	return time.Duration(php_serialize.PhpValueInt64(v)) * time.Second
+}
+
+// SetLastUsed updates the time of the last request.
+func (s *Session) SetLastUsed(t time.Time) {
+	meta := s.bag(KEY_META)
+	meta[META_UPDATED] = int(t.Unix())
+	s.session.Set(KEY_META, meta)
+}
+
+// StampNew resets the metadata like MetadataBag::stampNew does for new and migrated sessions.
+func (s *Session) StampNew(now time.Time, lifetime time.Duration) {
+	meta := s.bag(KEY_META)
+	meta[META_CREATED] = int(now.Unix())
+	meta[META_UPDATED] = int(now.Unix())
+	meta[META_LIFETIME] = int(lifetime / time.Second)
+	s.session.Set(KEY_META, meta)
+}
+
+func (s *Session) metaTime(key string) time.Time {
+	v, ok := s.bag(KEY_META)[key]
+	if !ok {
+		return time.Time{}
+	}
+	return time.Unix(php_serialize.PhpValueInt64(v), 0)
+}
+
+// bag returns a copy of the bag array, missing bag is empty.
+func (s *Session) bag(name string) php_serialize.PhpArray {
+	v, _ := s.session.Get(name)
+	original, _ := v.(php_serialize.PhpArray)
+	return copyArray(original)
+}
+
+func copyArray(array php_serialize.PhpArray) php_serialize.PhpArray {
+	copied := make(php_serialize.PhpArray, len(array))
+	for k, v := range array {
+		copied[k] = v
+	}
+	return copied
+}
+
+// setPath stores the value in nested arrays, the arrays on the path are copied.
+func setPath(array php_serialize.PhpArray, parts []string, value php_serialize.PhpValue) {
+	if len(parts) == 1 {
+		array[parts[0]] = value
+		return
+	}
+	nested, _ := array[parts[0]].(php_serialize.PhpArray)
+	nested = copyArray(nested)
+	setPath(nested, parts[1:], value)
+	array[parts[0]] = nested
+}
+
+func removePath(array php_serialize.PhpArray, parts []string) (php_serialize.PhpValue, bool) {
+	if len(parts) == 1 {
+		value, ok := array[parts[0]]
+		delete(array, parts[0])
+		return value, ok
+	}
+	nested, ok := array[parts[0]].(php_serialize.PhpArray)
+	if !ok {
+		return nil, false
+	}
+	nested = copyArray(nested)
+	value, ok := removePath(nested, parts[1:])
+	if ok {
+		array[parts[0]] = nested
+	}
+	return value, ok
+}
+
+// listValues returns values of PHP list ordered by their indexes.
+func listValues(v php_serialize.PhpValue) []php_serialize.PhpValue {
+	array, _ := v.(php_serialize.PhpArray)
+	indexes := make([]int, 0, len(array))
+	for k := range array {
+		if i, ok := k.(int); ok {
+			indexes = append(indexes, i)
+		}
+	}
+	sort.Ints(indexes)
+
+	values := make([]php_serialize.PhpValue, len(indexes))
+	for i, index := range indexes {
+		values[i] = array[index]
+	}
+	return values
+}
+
+func sortedKeys(array php_serialize.PhpArray) []string {
+	keys := make([]string, 0, len(array))
+	for k := range array {
+		if key, ok := k.(string); ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
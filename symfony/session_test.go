@@ -0,0 +1,122 @@
+package symfony
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/solidwall/php_session_decoder"
+	"github.com/solidwall/php_session_decoder/php_serialize"
+)
+
+const testSession = `_sf2_attributes|a:2:{s:18:"_csrf/authenticate";s:5:"token";s:4:"cart";a:1:{s:5:"items";i:3;}}` +
+	`_sf2_flashes|a:1:{s:7:"success";a:2:{i:0;s:5:"Saved";i:1;s:4:"Done";}}` +
+	`_sf2_meta|a:3:{s:1:"u";i:1700000100;s:1:"c";i:1700000000;s:1:"l";i:3600;}`
+
+func decodeTestSession(t *testing.T) *Session {
+	session, err := php_session_decoder.Decode(testSession)
+	if err != nil {
+		t.Fatalf("Can not decode session: %v\n", err)
+	}
+	return NewSession(session)
+}
+
+func TestAttributes(t *testing.T) {
+	session := decodeTestSession(t)
+
+	if v, _ := session.Get("_csrf/authenticate"); v != "token" {
+		t.Errorf("Attribute with slash was read incorrectly: %#v\n", v)
+	}
+	if v, _ := session.Get("cart/items"); v != 3 {
+		t.Errorf("Namespaced attribute was read incorrectly: %#v\n", v)
+	}
+	if session.Has("cart/total") || session.Has("missing") {
+		t.Errorf("Missing attributes should not be found\n")
+	}
+	if names := session.AttributeNames(); !reflect.DeepEqual(names, []string{"_csrf/authenticate", "cart"}) {
+		t.Errorf("Attribute names are incorrect: %v\n", names)
+	}
+
+	session.Set("cart/total", 10)
+	session.Set("user", "admin")
+	if v, _ := session.Remove("cart/items"); v != 3 {
+		t.Errorf("Namespaced attribute was removed incorrectly: %#v\n", v)
+	}
+	if names := session.PhpSession().ChangedNames(); !reflect.DeepEqual(names, []string{KEY_ATTRIBUTES}) {
+		t.Errorf("Only attributes should be changed: %v\n", names)
+	}
+
+	encoded, err := php_session_decoder.Encode(session.PhpSession())
+	if err != nil {
+		t.Fatalf("Can not encode session: %v\n", err)
+	}
+	if !strings.HasSuffix(encoded, testSession[strings.Index(testSession, "_sf2_flashes"):]) {
+		t.Errorf("Unchanged bags should be encoded as they were: %q\n", encoded)
+	}
+	decoded, _ := php_session_decoder.Decode(encoded)
+	attributes, _ := decoded.Get(KEY_ATTRIBUTES)
+	expected := php_serialize.PhpArray{
+		"_csrf/authenticate": "token",
+		"cart":               php_serialize.PhpArray{"total": 10},
+		"user":               "admin",
+	}
+	if !reflect.DeepEqual(attributes, expected) {
+		t.Errorf("Attributes were encoded incorrectly: %#v\n", attributes)
+	}
+}
+
+func TestFlashes(t *testing.T) {
+	session := decodeTestSession(t)
+
+	if messages := session.PeekFlashes("success"); !reflect.DeepEqual(messages, []php_serialize.PhpValue{"Saved", "Done"}) {
+		t.Errorf("Flashes were peeked incorrectly: %v\n", messages)
+	}
+	if session.PhpSession().Changed() {
+		t.Errorf("Peek should not change the session\n")
+	}
+
+	if messages := session.GetFlashes("success"); len(messages) != 2 {
+		t.Errorf("Flashes were read incorrectly: %v\n", messages)
+	}
+	if messages := session.GetFlashes("success"); len(messages) != 0 {
+		t.Errorf("Flashes were not removed: %v\n", messages)
+	}
+
+	session.AddFlash("error", "Failed")
+	session.AddFlash("error", "Again")
+	if types := session.FlashTypes(); !reflect.DeepEqual(types, []string{"error"}) {
+		t.Errorf("Flash types are incorrect: %v\n", types)
+	}
+	if messages := session.PeekFlashes("error"); !reflect.DeepEqual(messages, []php_serialize.PhpValue{"Failed", "Again"}) {
+		t.Errorf("Flashes were added incorrectly: %v\n", messages)
+	}
+}
+
+func TestMeta(t *testing.T) {
+	session := decodeTestSession(t)
+
+	if created := session.Created(); !created.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("Created time is incorrect: %v\n", created)
+	}
+	if used := session.LastUsed(); !used.Equal(time.Unix(1700000100, 0)) {
+		t.Errorf("Last used time is incorrect: %v\n", used)
+	}
+	if lifetime := session.Lifetime(); lifetime != time.Hour {
+		t.Errorf("Lifetime is incorrect: %v\n", lifetime)
+	}
+
+	session.SetLastUsed(time.Unix(1700000200, 0))
+	if used := session.LastUsed(); !used.Equal(time.Unix(1700000200, 0)) {
+		t.Errorf("Last used time was updated incorrectly: %v\n", used)
+	}
+
+	empty := NewSession(php_session_decoder.NewPhpSession())
+	if !empty.Created().IsZero() {
+		t.Errorf("Created time of session without meta should be zero\n")
+	}
+	empty.StampNew(time.Unix(1700000000, 0), 0)
+	if meta, _ := empty.PhpSession().Get(KEY_META); !reflect.DeepEqual(meta, php_serialize.PhpArray{"c": 1700000000, "u": 1700000000, "l": 0}) {
+		t.Errorf("Meta was stamped incorrectly: %#v\n", meta)
+	}
+}
@@ -0,0 +1,2 @@
+// Package wordpress provides WordPress helpers for serialized options, meta and session tokens.
+package wordpress
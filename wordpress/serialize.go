@@ -0,0 +1,118 @@
+package wordpress
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/solidwall/php_session_decoder/php_serialize"
+)
+
+// PHP trim strips these chars by default
+const trimChars = " \t\n\r\x00\x0B"
+
+var (
+	reSerializedLength = regexp.MustCompile(`^[saOE]:[0-9]+:`)
+	reSerializedScalar = regexp.MustCompile(`^[bid]:[0-9.E+-]+;`)
+)
+
+// IsSerialized reports whether the data looks serialized the same way is_serialized does,
+// strict mode requires the data to end with `;` or `}`.
+func IsSerialized(data string, strict bool) bool {
+	data = strings.Trim(data, trimChars)
+	if data == "N;" {
+		return true
+	}
+	if len(data) < 4 || data[1] != ':' {
+		return false
+	}
+
+	if strict {
+		if last := data[len(data)-1]; last != ';' && last != '}' {
+			return false
+		}
+	} else {
+		semicolon := strings.IndexByte(data, ';')
+		brace := strings.IndexByte(data, '}')
+		if semicolon < 0 && brace < 0 {
+			return false
+		}
+		if semicolon >= 0 && semicolon < 3 || brace >= 0 && brace < 4 {
+			return false
+		}
+	}
+
+	switch data[0] {
+	case 's':
+		if strict && data[len(data)-2] != '"' || !strict && !strings.Contains(data, `"`) {
+			return false
+		}
+		return reSerializedLength.MatchString(data)
+	case 'a', 'O', 'E':
+		return reSerializedLength.MatchString(data)
+	case 'b', 'i', 'd':
+		match := reSerializedScalar.FindString(data)
+		return match != "" && (!strict || len(match) == len(data))
+	}
+	return false
+}
+
+// MaybeSerialize serializes arrays and objects like maybe_serialize does. Strings which
+// look serialized are serialized again, so MaybeUnserialize returns them unchanged.
+// Other scalars are converted to strings like PHP does when they are stored in the database.
+func MaybeSerialize(value php_serialize.PhpValue) (string, error) {
+	switch v := value.(type) {
+	case string:
+		if IsSerialized(v, false) {
+			return php_serialize.Serialize(v)
+		}
+		return v, nil
+	case nil:
+		return "", nil
+	case bool:
+		if v {
+			return "1", nil
+		}
+		return "", nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return formatFloat(v), nil
+	}
+	return php_serialize.Serialize(value)
+}
+
+// formatFloat converts the float to string like PHP does with precision 14:
+// 0.3, 1.0E+20, 1.5E-5, INF.
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "INF"
+	case math.IsInf(v, -1):
+		return "-INF"
+	case math.IsNaN(v):
+		return "NAN"
+	}
+
+	s := strconv.FormatFloat(v, 'G', 14, 64)
+	mantissa, exponent, ok := strings.Cut(s, "E")
+	if !ok {
+		return s
+	}
+	// PHP keeps a fractional digit in the mantissa and no leading zeros in the exponent
+	if !strings.Contains(mantissa, ".") {
+		mantissa += ".0"
+	}
+	return mantissa + "E" + exponent[:1] + strings.TrimLeft(exponent[1:], "0")
+}
+
+// MaybeUnserialize unserializes the data if it looks serialized, otherwise the data is returned as is.
+func MaybeUnserialize(data string) (php_serialize.PhpValue, error) {
+	if !IsSerialized(data, true) {
+		return data, nil
+	}
+	return php_serialize.UnSerialize(strings.Trim(data, trimChars))
+}
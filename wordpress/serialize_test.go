@@ -0,0 +1,93 @@
+package wordpress
+
+import (
+	"math"
+	"testing"
+
+	"github.com/solidwall/php_session_decoder/php_serialize"
+)
+
+func TestIsSerialized(t *testing.T) {
+	cases := []struct {
+		data   string
+		strict bool
+		result bool
+	}{
+		{"N;", true, true},
+		{" s:3:\"abc\"; ", true, true},
+		{`a:1:{i:0;s:1:"a";}`, true, true},
+		{`O:8:"stdClass":0:{}`, true, true},
+		{`E:7:"Foo:Bar";`, true, true},
+		{"i:42;", true, true},
+		{"d:1.5E+25;", true, true},
+		{"b:1;", true, true},
+		{"i:42;x", true, false},
+		{"i:42;x", false, true},
+		{`s:3:"abc";trailing`, false, true},
+		{`s:3:"abc";trailing`, true, false},
+		{"C:3:\"Foo\":0:{}", true, false},
+		{"hello", true, false},
+		{"a:", true, false},
+		{"i:;", true, false},
+		{"x:1;", true, false},
+	}
+
+	for _, c := range cases {
+		if result := IsSerialized(c.data, c.strict); result != c.result {
+			t.Errorf("IsSerialized(%q, %v) should be %v\n", c.data, c.strict, c.result)
+		}
+	}
+}
+
+func TestMaybeSerialize(t *testing.T) {
+	cases := []struct {
+		value  php_serialize.PhpValue
+		result string
+	}{
+		{"hello", "hello"},
+		{42, "42"},
+		{true, "1"},
+		{false, ""},
+		{nil, ""},
+		{0.1 + 0.2, "0.3"},
+		{1e20, "1.0E+20"},
+		{1e-5, "1.0E-5"},
+		{-1.5e25, "-1.5E+25"},
+		{1.25e-7, "1.25E-7"},
+		{1e14, "1.0E+14"},
+		{1e13, "10000000000000"},
+		{0.0001, "0.0001"},
+		{math.Inf(1), "INF"},
+		{php_serialize.PhpArray{0: "a"}, `a:1:{i:0;s:1:"a";}`},
+		// already serialized strings are serialized twice
+		{`a:1:{i:0;s:1:"a";}`, `s:18:"a:1:{i:0;s:1:"a";}";`},
+		{"i:42;", `s:5:"i:42;";`},
+	}
+
+	for _, c := range cases {
+		if result, err := MaybeSerialize(c.value); err != nil {
+			t.Errorf("Can not serialize %#v: %v\n", c.value, err)
+		} else if result != c.result {
+			t.Errorf("%#v was serialized incorrectly: %q\n", c.value, result)
+		}
+	}
+}
+
+func TestMaybeUnserialize(t *testing.T) {
+	if value, err := MaybeUnserialize("hello"); err != nil || value != "hello" {
+		t.Errorf("Plain string should be returned as is: %#v %v\n", value, err)
+	}
+	if value, err := MaybeUnserialize("i:42;\n"); err != nil || value != 42 {
+		t.Errorf("Serialized value was unserialized incorrectly: %#v %v\n", value, err)
+	}
+
+	original := `a:1:{i:0;s:1:"a";}`
+	serialized, _ := MaybeSerialize(original)
+	if value, err := MaybeUnserialize(serialized); err != nil || value != original {
+		t.Errorf("Double serialized string should be unserialized once: %#v %v\n", value, err)
+	}
+
+	if _, err := MaybeUnserialize(`s:10:"abc";`); err == nil {
+		t.Errorf("Broken serialized data should not be unserialized\n")
+	}
+}
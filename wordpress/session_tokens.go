@@ -0,0 +1,90 @@
+package wordpress
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/solidwall/php_session_decoder/php_serialize"
+)
+
+// SESSION_TOKENS_META_KEY is the user meta with sessions of the user
+const SESSION_TOKENS_META_KEY = "session_tokens"
+
+// SessionToken is the login session stored by WP_User_Meta_Session_Tokens.
+type SessionToken struct {
+	Expiration time.Time
+	IP         string
+	UserAgent  string
+	Login      time.Time
+	// Extra holds other keys which plugins add with attach_session_information filter.
+	Extra php_serialize.PhpArray
+}
+
+// SessionTokens are sessions of the user by verifiers, i.e. hashes of tokens.
+type SessionTokens map[string]SessionToken
+
+// HashToken returns the verifier of the token from logged_in and auth cookies.
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// DecodeSessionTokens decodes the value of session_tokens user meta.
+func DecodeSessionTokens(data string) (SessionTokens, error) {
+	value, err := MaybeUnserialize(data)
+	if err != nil {
+		return nil, err
+	}
+	if s, ok := value.(string); ok && s == "" {
+		return SessionTokens{}, nil
+	}
+	array, ok := value.(php_serialize.PhpArray)
+	if !ok {
+		return nil, fmt.Errorf("wordpress: unexpected session tokens of type %T", value)
+	}
+
+	tokens := make(SessionTokens, len(array))
+	for k, v := range array {
+		verifier, ok := k.(string)
+		if !ok {
+			return nil, fmt.Errorf("wordpress: unexpected verifier %#v", k)
+		}
+
+		session := SessionToken{Extra: php_serialize.PhpArray{}}
+		switch v := v.(type) {
+		case int:
+			// sessions of early versions hold the expiration only
+			session.Expiration = time.Unix(int64(v), 0)
+		case php_serialize.PhpArray:
+			for name, value := range v {
+				switch name {
+				case "expiration":
+					session.Expiration = time.Unix(php_serialize.PhpValueInt64(value), 0)
+				case "login":
+					session.Login = time.Unix(php_serialize.PhpValueInt64(value), 0)
+				case "ip":
+					session.IP = php_serialize.PhpValueString(value)
+				case "ua":
+					session.UserAgent = php_serialize.PhpValueString(value)
+				default:
+					session.Extra[name] = value
+				}
+			}
+		default:
+			return nil, fmt.Errorf("wordpress: unexpected session %q of type %T", verifier, v)
+		}
+		tokens[verifier] = session
+	}
+	return tokens, nil
+}
+
+// Verify returns the session of the token if it is not expired yet, like WP_Session_Tokens::verify does.
+func (st SessionTokens) Verify(token string, now time.Time) (SessionToken, bool) {
+	session, ok := st[HashToken(token)]
+	if !ok || session.Expiration.Before(now) {
+		return SessionToken{}, false
+	}
+	return session, true
+}
@@ -0,0 +1,49 @@
+package wordpress
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSessionTokens(t *testing.T) {
+	verifier := HashToken("nD4KBfXdkKr3Ai8ZDHOyvxA1UwYEpTXu9Yx4ekCy0Vq")
+	data := fmt.Sprintf(`a:2:{s:64:"%s";a:5:{s:10:"expiration";i:1700172800;s:2:"ip";s:9:"127.0.0.1";`+
+		`s:2:"ua";s:11:"Mozilla/5.0";s:5:"login";i:1700000000;s:6:"device";s:6:"mobile";}`+
+		`s:64:"%064d";i:1600000000;}`, verifier, 0)
+
+	tokens, err := DecodeSessionTokens(data)
+	if err != nil {
+		t.Fatalf("Can not decode session tokens: %v\n", err)
+	}
+	if len(tokens) != 2 {
+		t.Errorf("Session tokens were decoded incorrectly: %#v\n", tokens)
+	}
+
+	session, ok := tokens.Verify("nD4KBfXdkKr3Ai8ZDHOyvxA1UwYEpTXu9Yx4ekCy0Vq", time.Unix(1700100000, 0))
+	if !ok {
+		t.Fatalf("Valid token was not verified\n")
+	}
+	if !session.Expiration.Equal(time.Unix(1700172800, 0)) || !session.Login.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("Session times are incorrect: %#v\n", session)
+	} else if session.IP != "127.0.0.1" || session.UserAgent != "Mozilla/5.0" || session.Extra["device"] != "mobile" {
+		t.Errorf("Session was decoded incorrectly: %#v\n", session)
+	}
+
+	if _, ok = tokens.Verify("nD4KBfXdkKr3Ai8ZDHOyvxA1UwYEpTXu9Yx4ekCy0Vq", time.Unix(1700172801, 0)); ok {
+		t.Errorf("Expired token should not be verified\n")
+	}
+	if _, ok = tokens.Verify("unknown", time.Unix(1700100000, 0)); ok {
+		t.Errorf("Unknown token should not be verified\n")
+	}
+	if old := tokens[fmt.Sprintf("%064d", 0)]; !old.Expiration.Equal(time.Unix(1600000000, 0)) {
+		t.Errorf("Session of early version was decoded incorrectly: %#v\n", old)
+	}
+
+	if tokens, err = DecodeSessionTokens(""); err != nil || len(tokens) != 0 {
+		t.Errorf("Empty meta should have no sessions: %#v %v\n", tokens, err)
+	}
+	if _, err = DecodeSessionTokens("i:1;"); err == nil {
+		t.Errorf("Unexpected meta should not be decoded\n")
+	}
+}
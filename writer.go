@@ -0,0 +1,31 @@
+package php_session_decoder
+
+import (
+	"bufio"
+	"io"
+
+	"github.com/solidwall/php_session_decoder/php_serialize"
+)
+
+// encodeTo runs encode over w, writers without WriteByte/WriteRune are buffered.
+// The first write error stops writing and is returned.
+func encodeTo(w io.Writer, encode func(php_serialize.Writer) error) error {
+	writer, ok := w.(php_serialize.Writer)
+	var buffered *bufio.Writer
+	if !ok {
+		buffered = bufio.NewWriter(w)
+		writer = buffered
+	}
+
+	ew := php_serialize.NewErrorWriter(writer)
+	err := encode(ew)
+	if writeErr := ew.Err(); writeErr != nil {
+		err = writeErr
+	}
+	if buffered != nil {
+		if flushErr := buffered.Flush(); err == nil {
+			err = flushErr
+		}
+	}
+	return err
+}